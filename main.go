@@ -3,24 +3,289 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
 
 	"github.com/thomux/ticketstats/ticketstats"
 )
 
+// usage describes the subcommands.
+const usage = `Usage: ticketstats <command> [flags]
+
+Commands:
+  report     generate the HTML and JSON reports (default)
+  sanitize   check the tickets and print the warnings, exit code 1 on warnings
+  clusters   print the ticket clusters
+  stats      print the resource and resolution statistics
+  show KEY   print the ticket with the given key
+
+Run "ticketstats <command> -h" for the flags of a command.
+`
+
+// command is a subcommand of the CLI.
+type command struct {
+	flags *flag.FlagSet
+	input *inputFlags
+	// positional arguments, e.g. the ticket key of show
+	args []string
+	run  func(cmd *command) int
+}
+
+// inputFlags groups the flags shared by all commands, which select the
+// issues to evaluate.
+type inputFlags struct {
+	path      string
+	project   string
+	component string
+	filter    string
+	jiraApi   string
+	jql       string
+	jiraUser  string
+	asOf      string
+	format    string
+	changelog string
+}
+
+// newCommand creates a command with the shared input flags.
+func newCommand(name string, run func(cmd *command) int) *command {
+	cmd := &command{
+		flags: flag.NewFlagSet(name, flag.ExitOnError),
+		input: &inputFlags{},
+		run:   run,
+	}
+
+	flags := cmd.flags
+	input := cmd.input
+	flags.StringVar(&input.path, "csv", "JiraExport.csv", "path to Jira ticket export (CSV, XML or JSON), a comma separated list of files or glob patterns")
+	flags.StringVar(&input.format, "inputFormat", "", "format of the Jira ticket export, csv, xml or json, default by file extension")
+	flags.StringVar(&input.project, "project", "", "Jira project key")
+	flags.StringVar(&input.component, "component", "", "Jira component name")
+	flags.StringVar(&input.filter, "filter", "", "filter expression, e.g. \"type = Bug AND created > -30d\"")
+	flags.StringVar(&input.jiraApi, "jiraApi", "", "Jira server URL for REST queries")
+	flags.StringVar(&input.jql, "jql", "", "JQL query, loads the issues using the Jira REST API")
+	flags.StringVar(&input.jiraUser, "jiraUser", "", "Jira user name (token is read from JIRA_TOKEN)")
+	flags.StringVar(&input.changelog, "changelog", "", "path to a CSV export of the status changes (Issue key;Date;From;To;Author)")
+	flags.StringVar(&input.asOf, "asof", "", "reference date of the report (config date format), default is now")
+
+	return cmd
+}
+
+// command.parse parses the command line arguments. Positional arguments
+// and flags may be mixed, e.g. "show PRJ-42 -csv export.csv".
+func (cmd *command) parse(args []string) {
+	cmd.args = make([]string, 0)
+	for {
+		cmd.flags.Parse(args)
+		args = cmd.flags.Args()
+		if len(args) == 0 {
+			return
+		}
+		cmd.args = append(cmd.args, args[0])
+		args = args[1:]
+	}
+}
+
+// options creates the evaluation options of the input flags.
+func (input *inputFlags) options(config ticketstats.Config) ticketstats.Options {
+	options := ticketstats.Options{
+		Project:   input.project,
+		Component: input.component,
+		Filter:    input.filter,
+		AsOf:      time.Now(),
+	}
+	if input.asOf != "" {
+		date, err := time.Parse(config.Formats.Date, input.asOf)
+		if err != nil {
+			fail("invalid -asof date: ", err)
+		}
+		// the report covers the whole reference day
+		options.AsOf = date.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return options
+}
+
+// source creates the issue source of the input flags.
+func (input *inputFlags) source(config ticketstats.Config) ticketstats.IssueSource {
+	if input.jql == "" {
+		return ticketstats.NewFileSource(input.path, input.format, config)
+	}
+	jiraSource := ticketstats.NewJiraSource(input.jiraApi, input.jql, config)
+	jiraSource.User = input.jiraUser
+	jiraSource.Token = os.Getenv("JIRA_TOKEN")
+	return jiraSource
+}
+
+// load loads the config and the issues selected by the input flags.
+func (cmd *command) load() (ticketstats.Config, ticketstats.Options,
+	[]*ticketstats.Issue) {
+	config := ticketstats.LoadConfig()
+	options := cmd.input.options(config)
+
+	if cmd.input.changelog != "" {
+		file, err := os.Open(cmd.input.changelog)
+		if err != nil {
+			fail(err)
+		}
+		defer file.Close()
+		options.Changelog = file
+	}
+
+	issues, err := ticketstats.LoadIssues(cmd.input.source(config), config,
+		options)
+	if err != nil {
+		fail(err)
+	}
+	return config, options, issues
+}
+
+// workCalendar creates the working calendar of the config, an invalid
+// calendar fails the command.
+func workCalendar(config ticketstats.Config) *ticketstats.Calendar {
+	calendar, err := ticketstats.NewCalendar(config)
+	if err != nil {
+		fail("calendar:", err)
+	}
+	return calendar
+}
+
+// fail logs the error and exits with exit code 2.
+func fail(v ...interface{}) {
+	log.Println(append([]interface{}{"ERROR:"}, v...)...)
+	os.Exit(2)
+}
+
 func main() {
-	var path string
-	var project string
-	var component string
+	commands := map[string]*command{
+		"report":   reportCommand(),
+		"sanitize": sanitizeCommand(),
+		"clusters": clustersCommand(),
+		"stats":    statsCommand(),
+		"show":     showCommand(),
+	}
+
+	// without a command the report is generated
+	name := "report"
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		name = args[0]
+		args = args[1:]
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
+	cmd.flags.Usage = func() {
+		fmt.Fprintf(cmd.flags.Output(), "Usage of %s:\n", name)
+		cmd.flags.PrintDefaults()
+		fmt.Fprintf(cmd.flags.Output(), "\n%s", usage)
+	}
+
+	cmd.parse(args)
+	os.Exit(cmd.run(cmd))
+}
+
+// reportCommand generates the reports.
+func reportCommand() *command {
 	var jiraBase string
 	var split bool
+	var format string
+
+	cmd := newCommand("report", func(cmd *command) int {
+		config, options, issues := cmd.load()
+		options.JiraBase = jiraBase
+		options.SplitByComponent = split
+		options.Format = format
+
+		err := ticketstats.EvaluateIssues(issues, config, options)
+		if err != nil {
+			fail(err)
+		}
+		return 0
+	})
+	cmd.flags.StringVar(&jiraBase, "jira", "", "Jira base URL")
+	cmd.flags.BoolVar(&split, "splitByComponent", true, "split result by components")
+	cmd.flags.StringVar(&format, "format", "html", "report formats, comma separated list of html and json")
+
+	return cmd
+}
+
+// sanitizeCommand prints the sanitize warnings. The exit code is 1 if
+// there are warnings.
+func sanitizeCommand() *command {
+	var all bool
+
+	cmd := newCommand("sanitize", func(cmd *command) int {
+		config, options, issues := cmd.load()
+
+		result := ticketstats.Sanitize(issues, !all, options.AsOf, config)
+		warnings := result.ToWarnings("", options.AsOf, config,
+			workCalendar(config))
+		warnings.Print(os.Stdout)
+
+		if warnings.Count > 0 {
+			return 1
+		}
+		return 0
+	})
+	cmd.flags.BoolVar(&all, "all", false, "check all work logs, not only the current and the previous month")
+
+	return cmd
+}
+
+// clustersCommand prints the ticket clusters.
+func clustersCommand() *command {
+	return newCommand("clusters", func(cmd *command) int {
+		config, _, issues := cmd.load()
+		ticketstats.PrintClusters(issues, config)
+		return 0
+	})
+}
+
+// statsCommand prints the resource and resolution statistics.
+func statsCommand() *command {
+	return newCommand("stats", func(cmd *command) int {
+		config, options, issues := cmd.load()
+
+		component := options.Component
+		if component == "" {
+			component = config.Component
+		}
+		report, err := ticketstats.GenerateReport(issues, component, config,
+			options)
+		if err != nil {
+			fail(err)
+		}
+		report.Resources.Print(os.Stdout)
+		if report.HasStatuses {
+			fmt.Println()
+			report.Statuses.Print(os.Stdout)
+		}
+		return 0
+	})
+}
 
-	flag.StringVar(&path, "csv", "JiraExport.csv", "path to Jira ticket export")
-	flag.StringVar(&project, "project", "", "Jira project key")
-	flag.StringVar(&component, "component", "", "Jira component name")
-	flag.StringVar(&jiraBase, "jira", "", "Jira base URL")
-	flag.BoolVar(&split, "splitByComponent", true, "split result by components")
+// showCommand prints the ticket with the given key.
+func showCommand() *command {
+	return newCommand("show", func(cmd *command) int {
+		if len(cmd.args) != 1 {
+			fmt.Fprintln(os.Stderr, "show needs exactly one ticket key")
+			return 2
+		}
+		key := cmd.args[0]
 
-	flag.Parse()
+		config, _, issues := cmd.load()
+		for _, issue := range issues {
+			if issue.Key == key {
+				fmt.Print(issue.ToString(config, workCalendar(config)))
+				return 0
+			}
+		}
 
-	ticketstats.Evaluate(path, project, component, jiraBase, true)
+		fmt.Fprintf(os.Stderr, "ticket %s not found\n", key)
+		return 1
+	})
 }
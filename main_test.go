@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+	"testing"
+)
+
+func TestParseShow(t *testing.T) {
+	cmd := showCommand()
+	cmd.parse([]string{"PRJ-42", "-csv", "export.csv"})
+	if len(cmd.args) != 1 || cmd.args[0] != "PRJ-42" ||
+		cmd.input.path != "export.csv" {
+		log.Println("TEST: flags after the key", cmd.args, cmd.input.path)
+		t.Fail()
+	}
+
+	cmd = showCommand()
+	cmd.parse([]string{"-project", "PRJ", "PRJ-42", "-asof", "2021-11-15"})
+	if len(cmd.args) != 1 || cmd.args[0] != "PRJ-42" ||
+		cmd.input.project != "PRJ" || cmd.input.asOf != "2021-11-15" {
+		log.Println("TEST: flags around the key", cmd.args, cmd.input)
+		t.Fail()
+	}
+
+	cmd = showCommand()
+	cmd.parse([]string{"-csv", "export.csv"})
+	if len(cmd.args) != 0 {
+		log.Println("TEST: key without argument", cmd.args)
+		t.Fail()
+	}
+}
@@ -0,0 +1,283 @@
+package ticketstats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dayLayout is the layout of the holiday keys.
+const dayLayout = "2006-01-02"
+
+// defaultWorkingDays are the working days of the default calendar.
+var defaultWorkingDays = []string{"Monday", "Tuesday", "Wednesday",
+	"Thursday", "Friday"}
+
+// Calendar is the working-time calendar used for FTE, remaining days, ages
+// in business days and the formatting of work values.
+type Calendar struct {
+	// working hours per day
+	HoursPerDay Work
+	workdays    map[time.Weekday]bool
+	holidays    map[string]bool
+	// sorted day keys of the holidays on working weekdays
+	workHolidays []string
+}
+
+// NewCalendar creates the working calendar of the config. Missing hours
+// per day and working days are taken from the default calendar, 8 hours
+// from Monday to Friday. The holidays of the holiday file are added.
+func NewCalendar(config Config) (*Calendar, error) {
+	calendar := &Calendar{
+		HoursPerDay: config.Calendar.HoursPerDay,
+		workdays:    make(map[time.Weekday]bool),
+		holidays:    make(map[string]bool),
+	}
+	if calendar.HoursPerDay <= 0 {
+		calendar.HoursPerDay = 8
+	}
+
+	days := config.Calendar.WorkingDays
+	if len(days) == 0 {
+		days = defaultWorkingDays
+	}
+	for _, name := range days {
+		day, err := parseWeekday(name)
+		if err != nil {
+			return nil, err
+		}
+		calendar.workdays[day] = true
+	}
+
+	layout := config.Formats.Date
+	if layout == "" {
+		layout = dayLayout
+	}
+	for _, holiday := range config.Calendar.Holidays {
+		date, err := time.Parse(layout, strings.TrimSpace(holiday))
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday %q", holiday)
+		}
+		calendar.holidays[date.Format(dayLayout)] = true
+	}
+
+	if config.Calendar.HolidayFile != "" {
+		holidays, err := loadHolidayFile(config.Calendar.HolidayFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, holiday := range holidays {
+			calendar.holidays[holiday] = true
+		}
+	}
+
+	calendar.workHolidays = make([]string, 0)
+	for key := range calendar.holidays {
+		date, err := time.Parse(dayLayout, key)
+		if err == nil && calendar.workdays[date.Weekday()] {
+			calendar.workHolidays = append(calendar.workHolidays, key)
+		}
+	}
+	sort.Strings(calendar.workHolidays)
+
+	return calendar, nil
+}
+
+// parseWeekday parses an English weekday name, e.g. "Monday" or "mon".
+func parseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || (len(name) >= 3 && strings.HasPrefix(full, name)) {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid weekday %q", name)
+}
+
+// Calendar.IsWorkday tests if the day of the date is a working day, i.e.
+// a working weekday which is no holiday.
+func (calendar *Calendar) IsWorkday(date time.Time) bool {
+	return calendar.workdays[date.Weekday()] &&
+		!calendar.holidays[date.Format(dayLayout)]
+}
+
+// Calendar.DaysPerWeek returns the number of working weekdays.
+func (calendar *Calendar) DaysPerWeek() int {
+	return len(calendar.workdays)
+}
+
+// Calendar.HoursPerWeek returns the working hours of a week without
+// holidays.
+func (calendar *Calendar) HoursPerWeek() Work {
+	return calendar.HoursPerDay * Work(calendar.DaysPerWeek())
+}
+
+// Calendar.WorkingDays returns the working days between start and end.
+// Partial days are counted by their share of the day. If end is before
+// start, the result is negative.
+func (calendar *Calendar) WorkingDays(start time.Time, end time.Time) float64 {
+	if end.Before(start) {
+		return -calendar.WorkingDays(end, start)
+	}
+
+	days := 0.0
+	year, month, day := start.Date()
+	dayStart := time.Date(year, month, day, 0, 0, 0, 0, start.Location())
+	for dayStart.Before(end) {
+		// whole weeks are counted without visiting each day
+		weeks := int(end.Sub(dayStart).Hours() / (24 * 7))
+		weekEnd := dayStart.AddDate(0, 0, 7*weeks)
+		if weekEnd.After(end) {
+			// daylight saving time
+			weeks--
+			weekEnd = dayStart.AddDate(0, 0, 7*weeks)
+		}
+		if !dayStart.Before(start) && weeks > 0 {
+			days += float64(weeks*calendar.DaysPerWeek() - calendar.Holidays(
+				dayStart.Add(-time.Nanosecond), weekEnd.Add(-time.Nanosecond)))
+			dayStart = weekEnd
+			continue
+		}
+
+		dayEnd := dayStart.AddDate(0, 0, 1)
+		if calendar.IsWorkday(dayStart) {
+			from := dayStart
+			if start.After(from) {
+				from = start
+			}
+			to := dayEnd
+			if end.Before(to) {
+				to = end
+			}
+			days += to.Sub(from).Hours() / dayEnd.Sub(dayStart).Hours()
+		}
+		dayStart = dayEnd
+	}
+	return days
+}
+
+// Calendar.Holidays returns the number of holidays on working weekdays
+// after start and not after end.
+func (calendar *Calendar) Holidays(start time.Time, end time.Time) int {
+	// day keys are ordered like the days, the holiday must be on a day after
+	// the day of start and not after the day of end
+	first := sort.SearchStrings(calendar.workHolidays,
+		start.Format(dayLayout)+"~")
+	last := sort.SearchStrings(calendar.workHolidays,
+		end.Format(dayLayout)+"~")
+	if last < first {
+		return 0
+	}
+	return last - first
+}
+
+// Calendar.Capacity returns the working hours of a full-time employee
+// within the window, i.e. the working days of the window without weekends
+// and holidays. The capacity is at least one working day.
+func (calendar *Calendar) Capacity(window Window) Work {
+	capacity := Work(calendar.WorkingDays(window.Start, window.End)) *
+		calendar.HoursPerDay
+	if capacity < calendar.HoursPerDay {
+		return calendar.HoursPerDay
+	}
+	return capacity
+}
+
+// Calendar.FormatWork converts work hours to a string using the working
+// days and weeks of the calendar, e.g. "1w 2d 4.00h".
+func (calendar *Calendar) FormatWork(w Work) string {
+	return formatWorkDays(w, calendar.HoursPerDay, calendar.DaysPerWeek())
+}
+
+// loadHolidayFile returns the holidays of the ICS file as day keys.
+func loadHolidayFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	holidays, err := parseIcs(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return holidays, nil
+}
+
+// parseIcs returns the days of all events of an iCalendar file as day
+// keys. Events spanning several days, i.e. with a DTEND after the next
+// day, add all days until the exclusive end.
+func parseIcs(r io.Reader) ([]string, error) {
+	holidays := make([]string, 0)
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// folded lines continue with a space or tab
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") ||
+			strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var start, end time.Time
+	inEvent := false
+	for _, line := range lines {
+		name, value := icsProperty(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent = true
+			start = time.Time{}
+			end = time.Time{}
+		case name == "END" && value == "VEVENT":
+			inEvent = false
+			if start.IsZero() {
+				continue
+			}
+			holidays = append(holidays, start.Format(dayLayout))
+			for day := start.AddDate(0, 0, 1); day.Before(end); day = day.AddDate(0, 0, 1) {
+				holidays = append(holidays, day.Format(dayLayout))
+			}
+		case inEvent && (name == "DTSTART" || name == "DTEND"):
+			if len(value) < 8 {
+				return nil, fmt.Errorf("invalid date %q", value)
+			}
+			date, err := time.Parse("20060102", value[:8])
+			if err != nil {
+				return nil, fmt.Errorf("invalid date %q", value)
+			}
+			if name == "DTSTART" {
+				start = date
+			} else {
+				end = date
+			}
+		}
+	}
+
+	return holidays, nil
+}
+
+// icsProperty splits an iCalendar content line into the property name,
+// without parameters, and the value.
+func icsProperty(line string) (string, string) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return "", ""
+	}
+	name := line[:i]
+	if j := strings.Index(name, ";"); j >= 0 {
+		name = name[:j]
+	}
+	return strings.ToUpper(name), strings.TrimSpace(line[i+1:])
+}
@@ -0,0 +1,217 @@
+package ticketstats
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWorkingDays(t *testing.T) {
+	calendar := testCalendar
+
+	// Monday noon to Monday noon two weeks later
+	days := calendar.WorkingDays(testNow, testNow.AddDate(0, 0, 14))
+	if math.Abs(days-10) > 0.001 {
+		log.Println("TEST: wrong working days", days)
+		t.Fail()
+	}
+	days = calendar.WorkingDays(testNow.AddDate(0, 0, 14), testNow)
+	if math.Abs(days+10) > 0.001 {
+		log.Println("TEST: wrong negative working days", days)
+		t.Fail()
+	}
+	// Saturday to Monday
+	saturday := time.Date(2021, 11, 13, 0, 0, 0, 0, time.UTC)
+	if calendar.IsWorkday(saturday) ||
+		calendar.WorkingDays(saturday, saturday.AddDate(0, 0, 2)) != 0 {
+		log.Println("TEST: weekend is a working day")
+		t.Fail()
+	}
+}
+
+func TestCalendarHolidays(t *testing.T) {
+	config := DefaultConfig()
+	config.Calendar.HoursPerDay = 7.5
+	config.Calendar.WorkingDays = []string{"mon", "Tuesday", "Wed",
+		"thursday"}
+	config.Calendar.Holidays = []string{"2021-11-16"}
+	calendar, err := NewCalendar(config)
+	if err != nil {
+		log.Println("TEST: calendar failed", err)
+		t.FailNow()
+	}
+
+	if calendar.DaysPerWeek() != 4 || calendar.HoursPerWeek() != 30 {
+		log.Println("TEST: wrong week", calendar.HoursPerWeek())
+		t.Fail()
+	}
+	if calendar.IsWorkday(testNow.AddDate(0, 0, 1)) ||
+		calendar.IsWorkday(testNow.AddDate(0, 0, 4)) {
+		log.Println("TEST: holiday or Friday is a working day")
+		t.Fail()
+	}
+	days := calendar.WorkingDays(testNow, testNow.AddDate(0, 0, 7))
+	if math.Abs(days-3) > 0.001 {
+		log.Println("TEST: wrong working days", days)
+		t.Fail()
+	}
+
+	window := Window{
+		Start: testNow,
+		End:   testNow.AddDate(0, 0, 7),
+	}
+	if calendar.Capacity(window) != 22.5 {
+		log.Println("TEST: wrong capacity", calendar.Capacity(window))
+		t.Fail()
+	}
+	if calendar.FormatWork(37.5) != "1w 1d " {
+		log.Println("TEST: wrong work format", calendar.FormatWork(37.5))
+		t.Fail()
+	}
+
+	config.Calendar.WorkingDays = []string{"Someday"}
+	if _, err := NewCalendar(config); err == nil {
+		log.Println("TEST: invalid weekday accepted")
+		t.Fail()
+	}
+	config.Calendar.WorkingDays = nil
+	config.Calendar.Holidays = []string{"24.12.2021"}
+	if _, err := NewCalendar(config); err == nil {
+		log.Println("TEST: invalid holiday accepted")
+		t.Fail()
+	}
+}
+
+func TestCalendarDefaults(t *testing.T) {
+	// an older config file without calendar
+	var config Config
+	calendar, err := NewCalendar(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calendar.HoursPerWeek() != 40 || calendar.FormatWork(60) != "1w 2d 4.00h" {
+		log.Println("TEST: wrong default calendar", calendar.HoursPerWeek())
+		t.Fail()
+	}
+
+	window := DefaultConfig().TimeWindows(testNow)[0]
+	if calendar.Capacity(window) != 40 {
+		log.Println("TEST: wrong default capacity", calendar.Capacity(window))
+		t.Fail()
+	}
+}
+
+func TestHolidayFile(t *testing.T) {
+	config := DefaultConfig()
+	config.Calendar.HolidayFile = "testdata/holidays.ics"
+	calendar, err := NewCalendar(config)
+	if err != nil {
+		log.Println("TEST: holiday file failed", err)
+		t.FailNow()
+	}
+
+	for _, day := range []int{18, 24, 25, 26} {
+		month := time.December
+		if day == 18 {
+			month = time.November
+		}
+		date := time.Date(2021, month, day, 0, 0, 0, 0, time.UTC)
+		if !calendar.holidays[date.Format(dayLayout)] {
+			log.Println("TEST: missing holiday", date)
+			t.Fail()
+		}
+	}
+	if calendar.holidays["2021-12-27"] {
+		log.Println("TEST: exclusive event end is a holiday")
+		t.Fail()
+	}
+
+	// half a Monday and 31 working days without the weekends, the Thursday
+	// holiday and Christmas Friday
+	window := Window{
+		Start: testNow,
+		End:   time.Date(2021, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+	if calendar.Capacity(window) != 252 {
+		log.Println("TEST: wrong capacity", calendar.Capacity(window))
+		t.Fail()
+	}
+
+	// six weeks from Monday to Monday without both holidays on weekdays
+	days := calendar.WorkingDays(testNow,
+		testNow.AddDate(0, 0, 42))
+	if math.Abs(days-28) > 0.001 {
+		log.Println("TEST: wrong working days", days)
+		t.Fail()
+	}
+
+	config.Calendar.HolidayFile = "testdata/missing.ics"
+	if _, err := NewCalendar(config); err == nil {
+		log.Println("TEST: missing holiday file accepted")
+		t.Fail()
+	}
+}
+
+func TestHolidayFileChanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "calendar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := DefaultConfig()
+	config.Calendar.HolidayFile = filepath.Join(dir, "holidays.ics")
+	for _, day := range []string{"20211118", "20211119"} {
+		err = ioutil.WriteFile(config.Calendar.HolidayFile, []byte(
+			"BEGIN:VEVENT\nDTSTART;VALUE=DATE:"+day+"\nEND:VEVENT\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the file is read again for each calendar
+		calendar, err := NewCalendar(config)
+		if err != nil || len(calendar.workHolidays) != 1 ||
+			calendar.workHolidays[0] != day[:4]+"-"+day[4:6]+"-"+day[6:] {
+			log.Println("TEST: wrong holidays", day, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestCalendarErrors(t *testing.T) {
+	config := DefaultConfig()
+	config.Calendar.WorkingDays = []string{"Someday"}
+
+	_, err := GenerateReport([]*Issue{NewIssue()}, "", config,
+		Options{AsOf: testNow})
+	if err == nil {
+		log.Println("TEST: report with invalid calendar")
+		t.Fail()
+	}
+	_, err = Run(context.Background(), Options{
+		Source: testSource{NewIssue()},
+		Config: &config,
+		AsOf:   testNow,
+	})
+	if err == nil {
+		log.Println("TEST: run with invalid calendar")
+		t.Fail()
+	}
+}
+
+func TestBusinessAge(t *testing.T) {
+	issue := NewIssue()
+	issue.Key = "A"
+	issue.Created = testNow.AddDate(0, 0, -10)
+
+	rissue := issue.ToReportIssue("", testNow, DefaultConfig(),
+		testCalendar)
+	if rissue.Age != 10 || rissue.BusinessAge != 6 {
+		log.Println("TEST: wrong ages", rissue.Age, rissue.BusinessAge)
+		t.Fail()
+	}
+}
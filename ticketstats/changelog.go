@@ -0,0 +1,282 @@
+package ticketstats
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/montanaflynn/stats"
+)
+
+// StatusChange is a status transition of an issue.
+type StatusChange struct {
+	Date time.Time
+	From string
+	To   string
+	// user who changed the status
+	Author string
+}
+
+// statusPeriod is a time span an issue spent in a status.
+type statusPeriod struct {
+	Status string
+	Start  time.Time
+	End    time.Time
+}
+
+// statusPeriod.days returns the length of the period in days.
+func (period statusPeriod) days() float64 {
+	return period.End.Sub(period.Start).Hours() / 24.0
+}
+
+// Issue.addStatusChange adds a status transition to the history, if it is
+// not yet known. The history is kept sorted by date.
+func (issue *Issue) addStatusChange(change StatusChange) {
+	for _, c := range issue.History {
+		if c.Date.Equal(change.Date) && c.From == change.From &&
+			c.To == change.To {
+			return
+		}
+	}
+	issue.History = append(issue.History, change)
+	sort.SliceStable(issue.History, func(i, j int) bool {
+		return issue.History[i].Date.Before(issue.History[j].Date)
+	})
+}
+
+// ParseChangelog parses a changelog CSV export, e.g. of a Jira automation
+// or database query. The data is separated by semicolons like the Jira CSV
+// export and has the columns "Issue key", "Date", "From", "To" and the
+// optional column "Author". The dates use the accepted Jira date layouts.
+// The result maps the issue keys to the status changes.
+func ParseChangelog(r io.Reader, config Config) (map[string][]StatusChange,
+	error) {
+	records, err := readCsv(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("changelog without header")
+	}
+
+	columns := make(map[string]int)
+	for i, column := range records[0] {
+		columns[strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))] = i
+	}
+	for _, required := range []string{"Issue key", "Date", "From", "To"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("changelog without column %q", required)
+		}
+	}
+	value := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	dates, err := newDateParser(config, "")
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string][]StatusChange)
+	for n, record := range records[1:] {
+		key := value(record, "Issue key")
+		date, err := dates.parse(value(record, "Date"))
+		if err != nil {
+			return nil, fmt.Errorf("changelog row %d: %v", n+2, err)
+		}
+		changes[key] = append(changes[key], StatusChange{
+			Date:   date,
+			From:   value(record, "From"),
+			To:     value(record, "To"),
+			Author: value(record, "Author"),
+		})
+	}
+
+	return changes, nil
+}
+
+// AddChangelog adds the status changes to the history of the issues with
+// matching keys. Known changes are skipped.
+func AddChangelog(issues []*Issue, changes map[string][]StatusChange) {
+	for _, issue := range issues {
+		for _, change := range changes[issue.Key] {
+			issue.addStatusChange(change)
+		}
+	}
+}
+
+// Issue.statusPeriods returns the time spans the issue spent in each status
+// until now. The status before the first change is the From status of the
+// change, without history it is the current status.
+func (issue *Issue) statusPeriods(now time.Time) []statusPeriod {
+	periods := make([]statusPeriod, 0)
+
+	status := issue.Status
+	if len(issue.History) > 0 {
+		status = issue.History[0].From
+	}
+	start := issue.Created
+
+	for _, change := range issue.History {
+		if change.Date.After(now) {
+			break
+		}
+		// without creation date, the time before the first change is unknown
+		if !start.IsZero() {
+			periods = append(periods, statusPeriod{
+				Status: status,
+				Start:  start,
+				End:    change.Date,
+			})
+		}
+		status = change.To
+		start = change.Date
+	}
+	if !start.IsZero() && start.Before(now) {
+		periods = append(periods, statusPeriod{
+			Status: status,
+			Start:  start,
+			End:    now,
+		})
+	}
+
+	return periods
+}
+
+// Issue.TimeInStatus returns the days the issue spent in each status until
+// now.
+func (issue *Issue) TimeInStatus(now time.Time) map[string]float64 {
+	times := make(map[string]float64)
+	for _, period := range issue.statusPeriods(now) {
+		times[period.Status] += period.days()
+	}
+	return times
+}
+
+// Issue.FlowEfficiency returns the share of the time the issue was actively
+// worked on, i.e. the time in the category in progress, of the time from
+// the first start of the work until it was done or now. The second value
+// is false if the work on the issue wasn't started.
+func (issue *Issue) FlowEfficiency(now time.Time, config Config) (float64,
+	bool) {
+	var active, total float64
+	started := false
+	for _, period := range issue.statusPeriods(now) {
+		category := config.statusCategory(issue.Key, period.Status)
+		if category == CategoryInProgress {
+			started = true
+			active += period.days()
+		}
+		if started && category != CategoryDone {
+			total += period.days()
+		}
+	}
+	if !started || total <= 0 {
+		return 0, false
+	}
+	return active / total, true
+}
+
+// Issue.Reopens returns how often the issue was reopened until now, i.e.
+// changed from a status of the category done to another category.
+func (issue *Issue) Reopens(now time.Time, config Config) int {
+	reopens := 0
+	for _, change := range issue.History {
+		if change.Date.After(now) {
+			break
+		}
+		if config.statusCategory(issue.Key, change.From) == CategoryDone &&
+			config.statusCategory(issue.Key, change.To) != CategoryDone {
+			reopens++
+		}
+	}
+	return reopens
+}
+
+// StatusTime groups the time in status statistics of a ticket list.
+// The unit is days.
+type StatusTime struct {
+	Status   string
+	Category string
+	Mean     float64
+	Median   float64
+	// number of tickets which were in the status
+	Count int
+}
+
+// StatusTimes calculates the time in status statistics of the tickets with
+// history. The statuses of the category done are skipped. The result is
+// ordered by category and status.
+func StatusTimes(issues []*Issue, now time.Time, config Config) []StatusTime {
+	days := make(map[string][]float64)
+	categories := make(map[string]string)
+	for _, issue := range issues {
+		if len(issue.History) == 0 {
+			continue
+		}
+		for status, d := range issue.TimeInStatus(now) {
+			category := config.statusCategory(issue.Key, status)
+			if category == CategoryDone {
+				continue
+			}
+			days[status] = append(days[status], d)
+			categories[status] = category
+		}
+	}
+
+	result := make([]StatusTime, 0)
+	for status, d := range days {
+		mean, _ := stats.Mean(d)
+		median, _ := stats.Median(d)
+		result = append(result, StatusTime{
+			Status:   status,
+			Category: categories[status],
+			Mean:     mean,
+			Median:   median,
+			Count:    len(d),
+		})
+	}
+
+	order := map[string]int{CategoryTodo: 0, CategoryInProgress: 1,
+		CategoryReview: 2}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Category != result[j].Category {
+			return order[result[i].Category] < order[result[j].Category]
+		}
+		return result[i].Status < result[j].Status
+	})
+
+	return result
+}
+
+// MeanFlowEfficiency calculates the mean flow efficiency of the tickets
+// with history and started work. The second value is the number of
+// considered tickets.
+func MeanFlowEfficiency(issues []*Issue, now time.Time,
+	config Config) (float64, int) {
+	values := make([]float64, 0)
+	for _, issue := range issues {
+		if len(issue.History) == 0 {
+			continue
+		}
+		efficiency, ok := issue.FlowEfficiency(now, config)
+		if ok {
+			values = append(values, efficiency)
+		}
+	}
+	mean, _ := stats.Mean(values)
+	return mean, len(values)
+}
+
+// Reopened reduces the tickets to the ones which were reopened until now.
+func Reopened(issues []*Issue, now time.Time, config Config) []*Issue {
+	return Filter(issues, func(issue *Issue) bool {
+		return issue.Reopens(now, config) > 0
+	})
+}
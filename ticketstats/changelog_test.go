@@ -0,0 +1,153 @@
+package ticketstats
+
+import (
+	"log"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// changelogTestData is the changelog of a bug which was reopened.
+const changelogTestData = "Issue key;Date;From;To;Author\n" +
+	"PRJ-1;2021-11-03 00:00;Open;In Progress;dev1\n" +
+	"PRJ-1;2021-11-05 00:00;In Progress;Review;dev1\n" +
+	"PRJ-1;2021-11-06 00:00;Review;In Progress;dev2\n" +
+	"PRJ-1;2021-11-08 00:00;In Progress;Closed;dev1\n" +
+	"PRJ-1;2021-11-10 00:00;Closed;Open;test1\n" +
+	"PRJ-2;2021-11-12 00:00;Open;Closed;dev1\n"
+
+func changelogTestIssues(t *testing.T) []*Issue {
+	bug := NewIssue()
+	bug.Key = "PRJ-1"
+	bug.Type = "Bug"
+	bug.Status = "Open"
+	bug.Created = time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	other := NewIssue()
+	other.Key = "PRJ-2"
+	other.Type = "Bug"
+	other.Status = "Closed"
+	other.Created = time.Date(2021, 11, 11, 0, 0, 0, 0, time.UTC)
+
+	changes, err := ParseChangelog(strings.NewReader(changelogTestData),
+		DefaultConfig())
+	if err != nil {
+		log.Println("TEST: parse changelog failed", err)
+		t.FailNow()
+	}
+	issues := []*Issue{bug, other}
+	AddChangelog(issues, changes)
+	return issues
+}
+
+func TestParseChangelog(t *testing.T) {
+	issues := changelogTestIssues(t)
+
+	history := issues[0].History
+	if len(history) != 5 || history[0].To != "In Progress" ||
+		history[4].From != "Closed" || history[2].Author != "dev2" {
+		log.Println("TEST: wrong history", issues[0].ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+
+	// known changes are not added twice
+	changes, _ := ParseChangelog(strings.NewReader(changelogTestData),
+		DefaultConfig())
+	AddChangelog(issues, changes)
+	if len(issues[0].History) != 5 {
+		log.Println("TEST: duplicate changes", len(issues[0].History))
+		t.Fail()
+	}
+
+	_, err := ParseChangelog(strings.NewReader("Issue key;Date\n"),
+		DefaultConfig())
+	if err == nil {
+		log.Println("TEST: missing columns accepted")
+		t.Fail()
+	}
+}
+
+func TestTimeInStatus(t *testing.T) {
+	issues := changelogTestIssues(t)
+	config := DefaultConfig()
+
+	times := issues[0].TimeInStatus(testNow)
+	expected := map[string]float64{
+		"Open":        7.5,
+		"In Progress": 4,
+		"Review":      1,
+		"Closed":      2,
+	}
+	for status, days := range expected {
+		if math.Abs(times[status]-days) > 0.001 {
+			log.Println("TEST: wrong time in", status, times[status])
+			t.Fail()
+		}
+	}
+
+	efficiency, ok := issues[0].FlowEfficiency(testNow, config)
+	if !ok || math.Abs(efficiency-4.0/10.5) > 0.001 {
+		log.Println("TEST: wrong flow efficiency", efficiency)
+		t.Fail()
+	}
+	if _, ok := issues[1].FlowEfficiency(testNow, config); ok {
+		log.Println("TEST: flow efficiency without work")
+		t.Fail()
+	}
+
+	if issues[0].Reopens(testNow, config) != 1 ||
+		issues[1].Reopens(testNow, config) != 0 {
+		log.Println("TEST: wrong reopens")
+		t.Fail()
+	}
+	// reopened after the reference date
+	asOf := time.Date(2021, 11, 9, 0, 0, 0, 0, time.UTC)
+	if issues[0].Reopens(asOf, config) != 0 ||
+		len(Reopened(issues, asOf, config)) != 0 {
+		log.Println("TEST: reopened after the reference date")
+		t.Fail()
+	}
+	reopened := Reopened(issues, testNow, config)
+	if len(reopened) != 1 || reopened[0].Key != "PRJ-1" {
+		log.Println("TEST: wrong reopened tickets", len(reopened))
+		t.Fail()
+	}
+}
+
+func TestStatusTimes(t *testing.T) {
+	issues := changelogTestIssues(t)
+
+	times := StatusTimes(issues, testNow, DefaultConfig())
+	// the done status Closed is skipped
+	if len(times) != 3 || times[0].Status != "Open" ||
+		times[1].Status != "In Progress" || times[2].Status != "Review" {
+		log.Println("TEST: wrong statuses", times)
+		t.FailNow()
+	}
+	if times[0].Count != 2 || math.Abs(times[0].Mean-4.25) > 0.001 {
+		log.Println("TEST: wrong open times", times[0])
+		t.Fail()
+	}
+}
+
+func TestReportStatuses(t *testing.T) {
+	issues := changelogTestIssues(t)
+
+	report, err := GenerateReport(issues, "", DefaultConfig(),
+		Options{AsOf: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.HasStatuses || len(report.Statuses.Types) != 1 ||
+		report.Statuses.Types[0].Count != 2 ||
+		report.Statuses.Types[0].FlowEfficiency != 38 {
+		log.Println("TEST: wrong status report", report.Statuses)
+		t.Fail()
+	}
+	if len(report.Statuses.Reopened) != 1 ||
+		report.Statuses.Reopened[0].Reopens != 1 {
+		log.Println("TEST: wrong reopened tickets", report.Statuses.Reopened)
+		t.Fail()
+	}
+}
@@ -0,0 +1,390 @@
+package ticketstats
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+)
+
+// Size and layout of the generated charts in pixels.
+const (
+	chartWidth       = 400
+	chartHeight      = 200
+	chartMarginLeft  = 40
+	chartMarginTop   = 24
+	chartMarginBelow = 20
+	chartRowHeight   = 28
+	chartLabelWidth  = 90
+)
+
+// chartColors are the colors of the chart series, the colors are reused
+// if there are more series.
+var chartColors = []string{
+	"#3e8ed0", "#48c78e", "#ffe08a", "#f14668", "#485fc7",
+	"#00d1b2", "#b86bff", "#ff9f43", "#7a7a7a", "#a0522d",
+}
+
+// chartSeries is a named list of values, one value for each chart label.
+type chartSeries struct {
+	Name   string
+	Values []float64
+}
+
+// chartColor returns the color of the i-th series.
+func chartColor(i int) string {
+	return chartColors[i%len(chartColors)]
+}
+
+// svgText escapes a string for the use in SVG text and attributes.
+func svgText(s string) string {
+	return template.HTMLEscapeString(s)
+}
+
+// svgStart writes the opening svg element.
+func svgStart(sb *strings.Builder, width int, height int) {
+	fmt.Fprintf(sb, `<svg xmlns="http://www.w3.org/2000/svg" `+
+		`class="chart" width="%d" height="%d" viewBox="0 0 %d %d" `+
+		`font-family="sans-serif" font-size="11">`,
+		width, height, width, height)
+}
+
+// svgPlot writes the opening svg element and the legend of a chart. The
+// chart is chartHeight high, plus the additional lines of the legend. The
+// top and bottom of the plot area are returned.
+func svgPlot(sb *strings.Builder, series []chartSeries) (int, int) {
+	var legend strings.Builder
+	top := chartMarginTop + svgLegend(&legend, series)
+	bottom := top + chartHeight - chartMarginTop - chartMarginBelow
+
+	svgStart(sb, chartWidth, bottom+chartMarginBelow)
+	sb.WriteString(legend.String())
+
+	return top, bottom
+}
+
+// svgLegend writes a legend for the series to the top of the chart. The
+// legend is wrapped into multiple lines if needed, the height of the
+// legend is returned.
+func svgLegend(sb *strings.Builder, series []chartSeries) int {
+	x := chartMarginLeft
+	y := 4
+	for i, s := range series {
+		width := 24 + 7*len(s.Name)
+		if x > chartMarginLeft && x+width > chartWidth {
+			x = chartMarginLeft
+			y += 16
+		}
+		fmt.Fprintf(sb, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`,
+			x, y, chartColor(i))
+		fmt.Fprintf(sb, `<text x="%d" y="%d">%s</text>`,
+			x+14, y+9, svgText(s.Name))
+		x += width
+	}
+	return y - 4
+}
+
+// svgAxis writes the value axis and the labels of the bar groups.
+// The plot area starts at top and ends at bottom.
+func svgAxis(sb *strings.Builder, labels []string, max float64,
+	unit string, top int, bottom int) {
+	svgValueAxis(sb, max, unit, top, bottom)
+
+	groupWidth := float64(chartWidth-chartMarginLeft) / float64(len(labels))
+	for i, label := range labels {
+		x := float64(chartMarginLeft) + groupWidth*(float64(i)+0.5)
+		fmt.Fprintf(sb, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`,
+			x, bottom+chartMarginBelow-6, svgText(label))
+	}
+}
+
+// svgValueAxis writes the base line and the minimum and maximum value.
+func svgValueAxis(sb *strings.Builder, max float64, unit string, top int,
+	bottom int) {
+	fmt.Fprintf(sb, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#7a7a7a"/>`,
+		chartMarginLeft, bottom, chartWidth, bottom)
+	fmt.Fprintf(sb, `<text x="%d" y="%d" text-anchor="end">0</text>`,
+		chartMarginLeft-4, bottom)
+	fmt.Fprintf(sb, `<text x="%d" y="%d" text-anchor="end">%s%s</text>`,
+		chartMarginLeft-4, top+8, formatChartValue(max), unit)
+}
+
+// formatChartValue formats a value for chart labels.
+func formatChartValue(value float64) string {
+	if value == math.Trunc(value) {
+		return fmt.Sprintf("%.0f", value)
+	}
+	return fmt.Sprintf("%.1f", value)
+}
+
+// barChart renders a bar chart with one group of bars for each label and
+// one bar for each series within the group.
+func barChart(labels []string, series []chartSeries) template.HTML {
+	var sb strings.Builder
+
+	max := 0.0
+	for _, s := range series {
+		for _, value := range s.Values {
+			max = math.Max(max, value)
+		}
+	}
+	if max == 0.0 {
+		max = 1.0
+	}
+
+	top, bottom := svgPlot(&sb, series)
+	svgAxis(&sb, labels, max, "", top, bottom)
+
+	plotHeight := float64(bottom - top)
+	groupWidth := float64(chartWidth-chartMarginLeft) / float64(len(labels))
+	barWidth := groupWidth * 0.8 / float64(len(series))
+	for i := range labels {
+		x := float64(chartMarginLeft) + groupWidth*(float64(i)+0.1)
+		for j, s := range series {
+			value := s.Values[i]
+			height := value / max * plotHeight
+			y := float64(bottom) - height
+			fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" `+
+				`height="%.1f" fill="%s"><title>%s: %s</title></rect>`,
+				x, y, barWidth, height, chartColor(j),
+				svgText(s.Name), formatChartValue(value))
+			fmt.Fprintf(&sb, `<text x="%.1f" y="%.1f" `+
+				`text-anchor="middle">%s</text>`,
+				x+barWidth/2, y-2, formatChartValue(value))
+			x += barWidth
+		}
+	}
+
+	sb.WriteString("</svg>")
+	return template.HTML(sb.String())
+}
+
+// stackedChart renders a bar chart with one bar for each label. The bars
+// stack the series values as percentage of the sum of the label values.
+func stackedChart(labels []string, series []chartSeries) template.HTML {
+	var sb strings.Builder
+
+	top, bottom := svgPlot(&sb, series)
+	svgAxis(&sb, labels, 100.0, "%", top, bottom)
+
+	plotHeight := float64(bottom - top)
+	groupWidth := float64(chartWidth-chartMarginLeft) / float64(len(labels))
+	for i := range labels {
+		sum := 0.0
+		for _, s := range series {
+			sum += s.Values[i]
+		}
+		if sum == 0.0 {
+			continue
+		}
+
+		x := float64(chartMarginLeft) + groupWidth*0.2 + groupWidth*float64(i)
+		y := float64(bottom)
+		for j, s := range series {
+			percent := s.Values[i] / sum * 100.0
+			height := percent / 100.0 * plotHeight
+			y -= height
+			fmt.Fprintf(&sb, `<rect x="%.1f" y="%.1f" width="%.1f" `+
+				`height="%.1f" fill="%s"><title>%s: %s (%.0f%%)</title></rect>`,
+				x, y, groupWidth*0.6, height, chartColor(j),
+				svgText(s.Name), formatChartValue(s.Values[i]), percent)
+		}
+	}
+
+	sb.WriteString("</svg>")
+	return template.HTML(sb.String())
+}
+
+// areaChart renders a stacked area chart, the first series is at the
+// bottom. Only the first and the last label are written, e.g. the first
+// and the last date of a time series.
+func areaChart(labels []string, series []chartSeries) template.HTML {
+	var sb strings.Builder
+
+	if len(labels) == 0 {
+		return ""
+	}
+
+	sums := make([]float64, len(labels))
+	max := 0.0
+	for _, s := range series {
+		for i, value := range s.Values {
+			sums[i] += value
+			max = math.Max(max, sums[i])
+		}
+	}
+	if max == 0.0 {
+		max = 1.0
+	}
+
+	top, bottom := svgPlot(&sb, series)
+	svgValueAxis(&sb, max, "", top, bottom)
+
+	plotWidth := float64(chartWidth - chartMarginLeft)
+	plotHeight := float64(bottom - top)
+	x := func(i int) float64 {
+		if len(labels) == 1 {
+			return float64(chartMarginLeft) + plotWidth/2
+		}
+		return float64(chartMarginLeft) +
+			plotWidth*float64(i)/float64(len(labels)-1)
+	}
+	y := func(value float64) float64 {
+		return float64(bottom) - value/max*plotHeight
+	}
+
+	// each area is the polygon between the upper and the lower line
+	lower := make([]float64, len(labels))
+	for j, s := range series {
+		upper := make([]float64, len(labels))
+		points := make([]string, 0)
+		for i := range labels {
+			upper[i] = lower[i] + s.Values[i]
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i),
+				y(upper[i])))
+		}
+		for i := len(labels) - 1; i >= 0; i-- {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i),
+				y(lower[i])))
+		}
+		last := s.Values[len(labels)-1]
+		fmt.Fprintf(&sb, `<polygon points="%s" fill="%s">`+
+			`<title>%s: %s</title></polygon>`,
+			strings.Join(points, " "), chartColor(j), svgText(s.Name),
+			formatChartValue(last))
+		lower = upper
+	}
+
+	fmt.Fprintf(&sb, `<text x="%d" y="%d">%s</text>`,
+		chartMarginLeft, bottom+chartMarginBelow-6, svgText(labels[0]))
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" text-anchor="end">%s</text>`,
+		chartWidth, bottom+chartMarginBelow-6,
+		svgText(labels[len(labels)-1]))
+
+	sb.WriteString("</svg>")
+	return template.HTML(sb.String())
+}
+
+// BugChart renders a bar chart of the created and resolved bugs of the
+// time windows.
+func BugChart(bugs ReportBugs) template.HTML {
+	if len(bugs.Ranges) == 0 {
+		return ""
+	}
+
+	labels := make([]string, 0)
+	created := chartSeries{Name: "Created", Values: make([]float64, 0)}
+	resolved := chartSeries{Name: "Resolved", Values: make([]float64, 0)}
+	for _, r := range bugs.Ranges {
+		labels = append(labels, r.TimeRange)
+		created.Values = append(created.Values, float64(r.Created))
+		resolved.Values = append(resolved.Values, float64(r.Resolved))
+	}
+	return barChart(labels, []chartSeries{created, resolved})
+}
+
+// UsageChart renders a stacked bar chart of the effort spend per type, e.g.
+// ticket type or label, for each time range of a resource usage row.
+func UsageChart(groups []ResourceGroup) template.HTML {
+	if len(groups) == 0 {
+		return ""
+	}
+
+	labels := make([]string, 0)
+	series := make([]chartSeries, 0)
+	index := make(map[string]int)
+	for i, group := range groups {
+		labels = append(labels, group.TimeRange)
+		for _, details := range group.Details {
+			j, ok := index[details.Type]
+			if !ok {
+				j = len(series)
+				index[details.Type] = j
+				series = append(series, chartSeries{
+					Name:   details.Type,
+					Values: make([]float64, len(groups)),
+				})
+			}
+			series[j].Values[i] = float64(details.Hours)
+		}
+	}
+
+	return stackedChart(labels, series)
+}
+
+// FeatureChart renders a bar chart of the progress and the needed FTEs of
+// the given features. Features at risk and features with overtime are
+// highlighted.
+func FeatureChart(features []ReportIssue) template.HTML {
+	var sb strings.Builder
+
+	if len(features) == 0 {
+		return ""
+	}
+
+	maxFTE := 1.0
+	for _, feature := range features {
+		maxFTE = math.Max(maxFTE, feature.FTEValue)
+	}
+
+	barWidth := float64(chartWidth-chartLabelWidth) / 2.0
+	height := chartMarginTop + chartRowHeight*len(features)
+
+	svgStart(&sb, chartWidth, height)
+	fmt.Fprintf(&sb, `<text x="%d" y="13">Progress</text>`, chartLabelWidth)
+	fmt.Fprintf(&sb, `<text x="%.1f" y="13">FTE</text>`,
+		float64(chartLabelWidth)+barWidth+4)
+
+	for i, feature := range features {
+		y := chartMarginTop + chartRowHeight*i
+		fmt.Fprintf(&sb, `<text x="0" y="%d"><title>%s</title>%s</text>`,
+			y+14, svgText(feature.Summary), svgText(feature.Key))
+
+		progressColor := chartColor(1)
+		if feature.Overtime {
+			progressColor = chartColor(3)
+		}
+		progress := math.Min(float64(feature.Progress), 100.0) / 100.0
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%.1f" height="8" `+
+			`fill="#ededed"/>`, chartLabelWidth, y+5, barWidth-8)
+		fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%.1f" height="8" `+
+			`fill="%s"><title>%d%%</title></rect>`,
+			chartLabelWidth, y+5, (barWidth-8)*progress, progressColor,
+			feature.Progress)
+
+		if feature.HasEstimate {
+			fteColor := chartColor(0)
+			if feature.AtRisk {
+				fteColor = chartColor(3)
+			}
+			x := float64(chartLabelWidth) + barWidth
+			fte := math.Max(feature.FTEValue, 0.0)
+			fmt.Fprintf(&sb, `<rect x="%.1f" y="%d" width="%.1f" `+
+				`height="8" fill="%s"><title>%.2f FTE</title></rect>`,
+				x, y+5, (barWidth-40)*fte/maxFTE, fteColor, feature.FTEValue)
+			fmt.Fprintf(&sb, `<text x="%.1f" y="%d">%.2f</text>`,
+				x+(barWidth-40)*fte/maxFTE+4, y+13, feature.FTEValue)
+		}
+	}
+
+	sb.WriteString("</svg>")
+	return template.HTML(sb.String())
+}
+
+// CumulativeFlowChart renders a stacked area chart of the daily ticket
+// counts per status category. The done tickets are at the bottom.
+func CumulativeFlowChart(dates []string, flow ReportFlowSeries) template.HTML {
+	series := make([]chartSeries, 0)
+	for i := len(flow.Categories) - 1; i >= 0; i-- {
+		category := flow.Categories[i]
+		values := make([]float64, 0)
+		for _, count := range category.Counts {
+			values = append(values, float64(count))
+		}
+		series = append(series, chartSeries{
+			Name:   category.Category,
+			Values: values,
+		})
+	}
+	return areaChart(dates, series)
+}
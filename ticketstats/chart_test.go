@@ -0,0 +1,89 @@
+package ticketstats
+
+import (
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestBugChart(t *testing.T) {
+	var bugs ReportBugs
+	bugs.Ranges = []ReportRangeCount{
+		{"Last week", ReportCount{Created: 2, Resolved: 1, Diff: 1}},
+		{"Last month", ReportCount{Created: 4, Resolved: 6, Diff: -2}},
+	}
+
+	chart := string(BugChart(bugs))
+	if !strings.HasPrefix(chart, "<svg") || !strings.HasSuffix(chart, "</svg>") {
+		log.Println("TEST: no svg", chart)
+		t.Fail()
+	}
+	if strings.Count(chart, "<title>Created: ") != 2 ||
+		strings.Count(chart, "<title>Resolved: ") != 2 {
+		log.Println("TEST: wrong bars", chart)
+		t.Fail()
+	}
+	// the month resolved bar is the highest bar
+	if !strings.Contains(chart, `y="24.0" width="72.0" height="156.0" fill="#48c78e"><title>Resolved: 6</title>`) {
+		log.Println("TEST: wrong scaling", chart)
+		t.Fail()
+	}
+}
+
+func TestUsageChart(t *testing.T) {
+	groups := []ResourceGroup{
+		{
+			TimeRange: "Week",
+			Details: []ResourceDetails{
+				{Type: "Bug", Hours: 6},
+				{Type: "Feature <new>", Hours: 2},
+			},
+		},
+		{
+			TimeRange: "Month",
+			Details: []ResourceDetails{
+				{Type: "Feature <new>", Hours: 10},
+			},
+		},
+	}
+
+	chart := string(UsageChart(groups))
+	if !strings.Contains(chart, "<title>Bug: 6 (75%)</title>") ||
+		!strings.Contains(chart, "<title>Feature &lt;new&gt;: 2 (25%)</title>") ||
+		!strings.Contains(chart, "<title>Feature &lt;new&gt;: 10 (100%)</title>") {
+		log.Println("TEST: wrong stacks", chart)
+		t.Fail()
+	}
+	if strings.Contains(chart, "<new>") {
+		log.Println("TEST: label not escaped", chart)
+		t.Fail()
+	}
+
+	if UsageChart([]ResourceGroup{}) != "" {
+		log.Println("TEST: chart for empty usage")
+		t.Fail()
+	}
+}
+
+func TestFeatureChart(t *testing.T) {
+	features := []ReportIssue{
+		{Key: "A", Progress: 50, HasEstimate: true, FTEValue: 0.5},
+		{Key: "B", Progress: 150, Overtime: true, HasEstimate: true,
+			FTEValue: 2.0, AtRisk: true},
+		{Key: "C"},
+	}
+
+	chart := string(FeatureChart(features))
+	if strings.Count(chart, "FTE</title>") != 2 {
+		log.Println("TEST: wrong FTE bars", chart)
+		t.Fail()
+	}
+	if strings.Count(chart, `fill="#f14668"`) != 2 {
+		log.Println("TEST: overtime and risk not highlighted", chart)
+		t.Fail()
+	}
+	if !strings.Contains(chart, `width="147.0" height="8" fill="#f14668"><title>150%</title>`) {
+		log.Println("TEST: progress not limited", chart)
+		t.Fail()
+	}
+}
@@ -6,7 +6,15 @@ import (
 )
 
 // ClusterIssues builds a tree for the tickets based on the Jira issue links.
-func ClusterIssues(issues []*Issue) {
+// The links are resolved using the link graph, i.e. a link exported only by
+// the linked issue is considered too. Issue.Links is set to all links of
+// the issue.
+func ClusterIssues(issues []*Issue, config Config) {
+	graph := NewLinkGraph(issues)
+	for _, issue := range issues {
+		issue.Links = graph.Links(issue.Key)
+	}
+
 	keyIndex := make(map[string]*Issue)
 	idIndex := make(map[string]*Issue)
 
@@ -25,7 +33,7 @@ func ClusterIssues(issues []*Issue) {
 	}
 
 	for _, issue := range issues {
-		for _, cloneKey := range issue.LinkCloners {
+		for _, cloneKey := range graph.Outward(issue.Key, LinkTypeCloners) {
 			clone, ok := keyIndex[cloneKey]
 			if !ok {
 				continue
@@ -34,20 +42,22 @@ func ClusterIssues(issues []*Issue) {
 				issue.Childs = append(issue.Childs, clone)
 				log.Println("DEBUG: cluster by clone", issue.Key, "->", clone.Key)
 			} else {
-				clone.Childs = append(issue.Childs, issue)
+				clone.Childs = append(clone.Childs, issue)
 				log.Println("DEBUG: cluster by clone", clone.Key, "->", issue.Key)
 			}
 		}
 
-		for _, duplicateKey := range issue.LinkDuplicates {
+		for _, duplicateKey := range graph.Outward(issue.Key, LinkTypeDuplicate) {
 			duplicate, ok := keyIndex[duplicateKey]
 			if !ok {
 				continue
 			}
-			if issue.Status == "Closed" && duplicate.Status != "Closed" {
-				duplicate.Childs = append(issue.Childs, issue)
+			issueClosed := config.IsClosed(issue)
+			duplicateClosed := config.IsClosed(duplicate)
+			if issueClosed && !duplicateClosed {
+				duplicate.Childs = append(duplicate.Childs, issue)
 				log.Println("DEBUG: cluster by duplicate closed 1", duplicate.Key, "->", issue.Key)
-			} else if issue.Status != "Closed" && duplicate.Status == "Closed" {
+			} else if !issueClosed && duplicateClosed {
 				issue.Childs = append(issue.Childs, duplicate)
 				log.Println("DEBUG: cluster by duplicate closed 2", issue.Key, "->", duplicate.Key)
 			} else {
@@ -55,13 +65,13 @@ func ClusterIssues(issues []*Issue) {
 					issue.Childs = append(issue.Childs, duplicate)
 					log.Println("DEBUG: cluster by duplicate created 1", issue.Key, "->", duplicate.Key)
 				} else {
-					duplicate.Childs = append(issue.Childs, issue)
+					duplicate.Childs = append(duplicate.Childs, issue)
 					log.Println("DEBUG: cluster by duplicate created 2", duplicate.Key, "->", issue.Key)
 				}
 			}
 		}
 
-		for _, splitKey := range issue.LinkIssueSplits {
+		for _, splitKey := range graph.Outward(issue.Key, LinkTypeSplit) {
 			split, ok := keyIndex[splitKey]
 			if !ok {
 				continue
@@ -70,16 +80,16 @@ func ClusterIssues(issues []*Issue) {
 			log.Println("DEBUG: cluster by splits", issue.Key, "->", split.Key)
 		}
 
-		for _, partKey := range issue.LinkParts {
+		for _, partKey := range graph.Outward(issue.Key, LinkTypePart) {
 			part, ok := keyIndex[partKey]
 			if !ok {
 				continue
 			}
-			part.Childs = append(issue.Childs, issue)
+			part.Childs = append(part.Childs, issue)
 			log.Println("DEBUG: cluster by parts", issue.Key, "->", part.Key)
 		}
 
-		for _, childKey := range issue.LinkParents {
+		for _, childKey := range graph.Outward(issue.Key, LinkTypeParent) {
 			child, ok := keyIndex[childKey]
 			if !ok {
 				continue
@@ -3,7 +3,6 @@ package ticketstats
 import (
 	"log"
 	"testing"
-	"time"
 )
 
 func TestRemoveDuplicates(t *testing.T) {
@@ -62,7 +61,7 @@ func TestClusterIssuesParents(t *testing.T) {
 	issue.Id = "B"
 	issues = append(issues, issue)
 
-	ClusterIssues(issues)
+	ClusterIssues(issues, DefaultConfig())
 
 	if len(issues[1].Childs) != 0 {
 		log.Println("TEST: child issue")
@@ -89,7 +88,7 @@ func TestClusterIssuesParts(t *testing.T) {
 	issue.Id = "B"
 	issues = append(issues, issue)
 
-	ClusterIssues(issues)
+	ClusterIssues(issues, DefaultConfig())
 
 	if len(issues[1].Childs) != 1 {
 		log.Println("TEST: parent issue")
@@ -116,7 +115,7 @@ func TestClusterIssuesSplits(t *testing.T) {
 	issue.Id = "B"
 	issues = append(issues, issue)
 
-	ClusterIssues(issues)
+	ClusterIssues(issues, DefaultConfig())
 
 	if len(issues[0].Childs) != 1 {
 		log.Println("TEST: parent issue")
@@ -135,18 +134,18 @@ func TestClusterIssuesClones(t *testing.T) {
 	issue := NewIssue()
 	issue.Key = "A"
 	issue.Id = "A"
-	issue.Created = time.Now().AddDate(0, 0, -7)
+	issue.Created = testNow.AddDate(0, 0, -7)
 	issue.LinkCloners = append(issue.LinkCloners, "B")
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
 	issue.Id = "B"
-	issue.Created = time.Now().AddDate(0, 0, -5)
+	issue.Created = testNow.AddDate(0, 0, -5)
 	issue.LinkCloners = append(issue.LinkCloners, "A")
 	issues = append(issues, issue)
 
-	ClusterIssues(issues)
+	ClusterIssues(issues, DefaultConfig())
 
 	if len(issues[0].Childs) != 1 {
 		log.Println("TEST: older issue should be parent")
@@ -165,18 +164,18 @@ func TestClusterIssuesDuplicatesNotClosed(t *testing.T) {
 	issue := NewIssue()
 	issue.Key = "A"
 	issue.Id = "A"
-	issue.Created = time.Now().AddDate(0, 0, -7)
+	issue.Created = testNow.AddDate(0, 0, -7)
 	issue.LinkDuplicates = append(issue.LinkDuplicates, "B")
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
 	issue.Id = "B"
-	issue.Created = time.Now().AddDate(0, 0, -5)
+	issue.Created = testNow.AddDate(0, 0, -5)
 	issue.LinkDuplicates = append(issue.LinkDuplicates, "A")
 	issues = append(issues, issue)
 
-	ClusterIssues(issues)
+	ClusterIssues(issues, DefaultConfig())
 
 	if len(issues[0].Childs) != 1 {
 		log.Println("TEST: older issue should be parent")
@@ -195,7 +194,7 @@ func TestClusterIssuesDuplicatesClosed(t *testing.T) {
 	issue := NewIssue()
 	issue.Key = "A"
 	issue.Id = "A"
-	issue.Created = time.Now().AddDate(0, 0, -7)
+	issue.Created = testNow.AddDate(0, 0, -7)
 	issue.LinkDuplicates = append(issue.LinkDuplicates, "B")
 	issue.Status = "Closed"
 	issues = append(issues, issue)
@@ -203,11 +202,11 @@ func TestClusterIssuesDuplicatesClosed(t *testing.T) {
 	issue = NewIssue()
 	issue.Key = "B"
 	issue.Id = "B"
-	issue.Created = time.Now().AddDate(0, 0, -5)
+	issue.Created = testNow.AddDate(0, 0, -5)
 	issue.LinkDuplicates = append(issue.LinkDuplicates, "A")
 	issues = append(issues, issue)
 
-	ClusterIssues(issues)
+	ClusterIssues(issues, DefaultConfig())
 
 	if len(issues[0].Childs) != 0 {
 		log.Println("TEST: closed issue should be child")
@@ -410,3 +409,44 @@ func TestClusters(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestClusterIssuesSeveralChilds(t *testing.T) {
+	issue := func(key string, created int) *Issue {
+		issue := NewIssue()
+		issue.Key = key
+		issue.Id = key
+		issue.Created = testNow.AddDate(0, 0, created)
+		return issue
+	}
+
+	// B and C are parts of A
+	a, b, c := issue("A", -7), issue("B", -5), issue("C", -3)
+	b.LinkParts = append(b.LinkParts, "A")
+	c.LinkParts = append(c.LinkParts, "A")
+	// E and F are younger clones of D
+	d, e, f := issue("D", -7), issue("E", -5), issue("F", -3)
+	e.LinkCloners = append(e.LinkCloners, "D")
+	f.LinkCloners = append(f.LinkCloners, "D")
+	// the closed issues H and I duplicate G
+	g, h, i := issue("G", -7), issue("H", -5), issue("I", -3)
+	h.Status = "Closed"
+	h.LinkDuplicates = append(h.LinkDuplicates, "G")
+	i.Status = "Closed"
+	i.LinkDuplicates = append(i.LinkDuplicates, "G")
+
+	ClusterIssues([]*Issue{a, b, c, d, e, f, g, h, i}, DefaultConfig())
+
+	for _, parent := range []*Issue{a, d, g} {
+		if len(parent.Childs) != 2 {
+			log.Println("TEST: lost childs of", parent.Key,
+				len(parent.Childs))
+			t.Fail()
+		}
+	}
+	for _, child := range []*Issue{b, c, e, f, h, i} {
+		if len(child.Childs) != 0 {
+			log.Println("TEST: child issue", child.Key)
+			t.Fail()
+		}
+	}
+}
@@ -2,25 +2,105 @@ package ticketstats
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"strings"
+	"time"
+)
+
+// Status categories used to group the Jira workflow states.
+const (
+	CategoryTodo       = "todo"
+	CategoryInProgress = "in progress"
+	CategoryReview     = "review"
+	CategoryDone       = "done"
 )
 
 // Config groups all configuration values.
 type Config struct {
-	Template  string
-	Component string
-	Project   string
-	Types     ConfigTypeNames
-	States    ConfigStateNames
-	Customs   ConfigCustomFields
-	Formats   ConfigFormats
+	Template string
+	// URL of an external stylesheet, if empty the embedded one is inlined
+	Stylesheet     string
+	History        string
+	Component      string
+	Project        string
+	Types          ConfigTypeNames
+	States         ConfigStateNames
+	Customs        ConfigCustomFields
+	Headers        ConfigHeaders
+	Efforts        ConfigEfforts
+	Windows        ConfigWindows
+	Calendar       ConfigCalendar
+	CumulativeFlow ConfigCumulativeFlow
+	// filter expressions by report section, e.g. "bugs", see ParseQuery
+	Filters map[string]string
+	Formats ConfigFormats
+}
+
+// ConfigEfforts groups the settings for the effort evaluation.
+type ConfigEfforts struct {
+	// hours a person may book per day, more is reported as overbooking
+	DailyMaximum Work
+}
+
+// ConfigWindows groups the time windows of the statistics.
+type ConfigWindows struct {
+	// windows used by the bug, other ticket and resource sections
+	Ranges []ConfigWindow
+	// first month of the fiscal year, 1 (default) is January
+	FiscalYearStart int
+}
+
+// ConfigWindow is a time window of the statistics.
+type ConfigWindow struct {
+	// display name, e.g. "Last week"
+	Name string
+	// "rolling" (default) or "calendar", see WindowRolling and WindowCalendar
+	Kind string
+	// number and unit, e.g. "7d" or "3m", units are d (days), w (weeks),
+	// m (months), q (quarters) and y (years)
+	Length string
+}
+
+// ConfigCalendar groups the working-time calendar used for FTE, remaining
+// days, ages in business days and the formatting of work values.
+type ConfigCalendar struct {
+	// working hours per day, default 8
+	HoursPerDay Work
+	// English names of the working weekdays, default Monday to Friday
+	WorkingDays []string
+	// holidays in the format of Formats.Date
+	Holidays []string
+	// optional iCalendar (ICS) file, all event days are holidays
+	HolidayFile string
+}
+
+// ConfigCumulativeFlow groups the settings of the cumulative flow diagram.
+type ConfigCumulativeFlow struct {
+	// number of days shown, ending with the reference date of the report
+	Days int
+}
+
+// ConfigHeaders groups the settings for the CSV column names, e.g. for
+// localized Jira exports.
+type ConfigHeaders struct {
+	// built-in column names preset, "en" (default) or "de"
+	Language string
+	// maps column names to the English column names, overriding the preset
+	Aliases map[string]string
 }
 
 // ConfigFormats groups format strings.
 type ConfigFormats struct {
 	Date     string
 	JiraDate string
+	// further accepted date layouts of the CSV export, the layout in use is
+	// detected from the first rows
+	JiraDates []string
+	// IANA time zone of the CSV export dates, e.g. "Europe/Berlin", default
+	// is UTC
+	TimeZone string
 }
 
 // ConfigTypeNames groups the type name strings.
@@ -32,8 +112,13 @@ type ConfigTypeNames struct {
 
 // ConfigStateNames groups the state name strings.
 type ConfigStateNames struct {
+	// state used if a state is not mapped to a category
 	Closed    string
 	BugFilter []string
+	// maps the states to the categories todo, in progress, review and done
+	Categories map[string]string
+	// per project key overrides of the state categories
+	Projects map[string]map[string]string
 }
 
 // ConfigCustomFields groups the custom field names.
@@ -43,6 +128,11 @@ type ConfigCustomFields struct {
 	Variant           string
 	Account           string
 	Category          string
+	// maps CSV column names of further custom fields to their value type
+	// (string, number, date or list)
+	Fields map[string]string
+	// names of the fields the resource usage is grouped by
+	Groups []string
 }
 
 // DefaultConfig creates a new Config with all settings initialized using
@@ -52,6 +142,15 @@ func DefaultConfig() Config {
 
 	config.Formats.Date = "2006-01-02"
 	config.Formats.JiraDate = "02/Jan/06 3:04 PM"
+	config.Formats.JiraDates = []string{
+		"2006-01-02 15:04",
+		"2006-01-02T15:04:05.000-0700",
+		"2006-01-02T15:04:05Z07:00",
+		"02.01.2006 15:04",
+		"02/01/2006 15:04",
+		"2006-01-02",
+	}
+	config.Formats.TimeZone = ""
 
 	config.Types.Bug = "Bug"
 	config.Types.Feature = "New Feature"
@@ -60,34 +159,151 @@ func DefaultConfig() Config {
 	config.States.Closed = "Closed"
 	config.States.BugFilter = []string{"Verification", "Acceptace",
 		"Integration", "Closed"}
+	config.States.Categories = map[string]string{
+		"Open":           CategoryTodo,
+		"To Do":          CategoryTodo,
+		"Backlog":        CategoryTodo,
+		"Analysis":       CategoryInProgress,
+		"In Progress":    CategoryInProgress,
+		"Implementation": CategoryInProgress,
+		"Review":         CategoryReview,
+		"In Review":      CategoryReview,
+		"Verification":   CategoryReview,
+		"Acceptance":     CategoryReview,
+		"Integration":    CategoryReview,
+		"Closed":         CategoryDone,
+		"Done":           CategoryDone,
+		"Resolved":       CategoryDone,
+		"Rejected":       CategoryDone,
+		"Won't Fix":      CategoryDone,
+	}
+	config.States.Projects = make(map[string]map[string]string)
 
 	config.Customs.ExternalId = "Custom field (External ID)"
 	config.Customs.SupplierReference = "Custom field (Supplier reference)"
 	config.Customs.Variant = "Custom field (ICAS Variant)"
 	config.Customs.Account = "Custom field (Booking Account)"
 	config.Customs.Category = "Custom field (Bug-Category)"
+	config.Customs.Fields = make(map[string]string)
+	config.Customs.Groups = make([]string, 0)
+
+	config.Headers.Language = "en"
+	config.Headers.Aliases = make(map[string]string)
+
+	config.Efforts.DailyMaximum = 10
+
+	config.Windows.Ranges = []ConfigWindow{
+		{Name: "Last week", Kind: WindowRolling, Length: "7d"},
+		{Name: "Last month", Kind: WindowRolling, Length: "1m"},
+		{Name: "Last quarter", Kind: WindowRolling, Length: "3m"},
+		{Name: "Last year", Kind: WindowRolling, Length: "1y"},
+	}
+	config.Windows.FiscalYearStart = 1
+
+	config.Calendar.HoursPerDay = 8
+	config.Calendar.WorkingDays = append([]string{}, defaultWorkingDays...)
+	config.Calendar.Holidays = make([]string, 0)
+	config.Calendar.HolidayFile = ""
+
+	config.CumulativeFlow.Days = 90
+
+	config.Filters = make(map[string]string)
 
 	return config
 }
 
-// saveConfig saves the default config as "config.json".
-func saveConfig() {
-	data, err := json.MarshalIndent(DefaultConfig(), "", "  ")
+// Config.DateLayouts returns the accepted date layouts of the CSV export,
+// Formats.JiraDate first.
+func (config Config) DateLayouts() []string {
+	layouts := make([]string, 0)
+	for _, layout := range append([]string{config.Formats.JiraDate},
+		config.Formats.JiraDates...) {
+		if layout != "" && !contains(layouts, layout) {
+			layouts = append(layouts, layout)
+		}
+	}
+	return layouts
+}
+
+// Config.DailyMaximum returns the hours a person may book per day. A
+// missing maximum, e.g. of a config file without Efforts, defaults to 10.
+func (config Config) DailyMaximum() Work {
+	if config.Efforts.DailyMaximum <= 0 {
+		return 10
+	}
+	return config.Efforts.DailyMaximum
+}
+
+// Config.Location returns the time zone of the CSV export dates.
+func (config Config) Location() (*time.Location, error) {
+	if config.Formats.TimeZone == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(config.Formats.TimeZone)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("invalid time zone %q: %v",
+			config.Formats.TimeZone, err)
+	}
+	return location, nil
+}
+
+// Config.StatusCategory returns the category of the issue state.
+// A project specific mapping (States.Projects) is preferred over the
+// general mapping (States.Categories). States which are not mapped are
+// done if they match States.Closed, else todo.
+func (config Config) StatusCategory(issue *Issue) string {
+	return config.statusCategory(issue.Key, issue.Status)
+}
+
+// Config.statusCategory returns the category of the given state for the
+// issue with the given key, see StatusCategory.
+func (config Config) statusCategory(key string, status string) string {
+	project := key
+	i := strings.LastIndex(project, "-")
+	if i > 0 {
+		project = project[:i]
+	}
+
+	if categories, ok := config.States.Projects[project]; ok {
+		if category, ok := categories[status]; ok {
+			return category
+		}
+	}
+	if category, ok := config.States.Categories[status]; ok {
+		return category
 	}
-	err = ioutil.WriteFile("config.json", data, 0644)
+	if status == config.States.Closed {
+		return CategoryDone
+	}
+	return CategoryTodo
+}
+
+// Config.IsClosed tests if the issue state is in the category done.
+func (config Config) IsClosed(issue *Issue) bool {
+	return config.StatusCategory(issue) == CategoryDone
+}
+
+// saveConfig saves the default config as "config.json".
+func saveConfig() error {
+	data, err := json.MarshalIndent(DefaultConfig(), "", "  ")
 	if err != nil {
-		panic(err)
+		return err
 	}
+	return ioutil.WriteFile("config.json", data, 0644)
 }
 
 // LoadConfig loads the config or returns a default config if loading fails.
+// If there is no "config.json" in the working directory, it is created
+// using the default values. Use LoadConfigFile to load a config without
+// side effects.
 func LoadConfig() Config {
 	data, err := ioutil.ReadFile("config.json")
 	if err != nil {
 		log.Println("INFO: no config file found")
-		saveConfig()
+		err = saveConfig()
+		if err != nil {
+			log.Println("ERROR: Config:", err)
+		}
 		return DefaultConfig()
 	}
 	var config Config
@@ -98,3 +314,24 @@ func LoadConfig() Config {
 	}
 	return config
 }
+
+// LoadConfigFile loads the config file at path. Values missing in the file
+// keep their default values. An error is returned for an invalid working
+// calendar, see config.Calendar.
+func LoadConfigFile(path string) (Config, error) {
+	config := DefaultConfig()
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("config %s: %v", path, err)
+	}
+	_, err = NewCalendar(config)
+	if err != nil {
+		return config, fmt.Errorf("config %s: calendar: %v", path, err)
+	}
+	return config, nil
+}
@@ -1,12 +1,18 @@
 package ticketstats
 
 import (
+	"io/ioutil"
+	"log"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
 func TestSaveConfig(t *testing.T) {
-	saveConfig()
+	err := saveConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	info, err := os.Stat("config.json")
 	if err != nil {
@@ -16,3 +22,86 @@ func TestSaveConfig(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestLoadConfigFile(t *testing.T) {
+	err := saveConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfigFile("config.json")
+	if err != nil || config.Types.Bug != DefaultConfig().Types.Bug {
+		log.Println("TEST: config not loaded", err)
+		t.Fail()
+	}
+
+	_, err = LoadConfigFile("missing.json")
+	if err == nil {
+		log.Println("TEST: missing config without error")
+		t.Fail()
+	}
+
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	err = ioutil.WriteFile(path,
+		[]byte(`{"Calendar": {"WorkingDays": ["Someday"]}}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadConfigFile(path)
+	if err == nil {
+		log.Println("TEST: invalid calendar without error")
+		t.Fail()
+	}
+}
+
+func TestStatusCategory(t *testing.T) {
+	config := DefaultConfig()
+	config.States.Projects["PRJ"] = map[string]string{
+		"Delivered": CategoryDone,
+		"Analysis":  CategoryTodo,
+	}
+
+	issue := NewIssue()
+	issue.Key = "ABC-1"
+	issue.Status = "Won't Fix"
+	if config.StatusCategory(issue) != CategoryDone || !config.IsClosed(issue) {
+		log.Println("TEST: Won't Fix not done")
+		t.Fail()
+	}
+
+	issue.Status = "Analysis"
+	if config.StatusCategory(issue) != CategoryInProgress {
+		log.Println("TEST: Analysis not in progress")
+		t.Fail()
+	}
+
+	issue.Key = "PRJ-12"
+	if config.StatusCategory(issue) != CategoryTodo {
+		log.Println("TEST: project override not used")
+		t.Fail()
+	}
+
+	issue.Status = "Delivered"
+	if !config.IsClosed(issue) {
+		log.Println("TEST: project state not done")
+		t.Fail()
+	}
+
+	issue.Key = "ABC-2"
+	if config.IsClosed(issue) {
+		log.Println("TEST: unmapped state closed")
+		t.Fail()
+	}
+
+	config.States.Categories = nil
+	issue.Status = config.States.Closed
+	if !config.IsClosed(issue) {
+		log.Println("TEST: fallback to closed state")
+		t.Fail()
+	}
+}
@@ -0,0 +1,184 @@
+package ticketstats
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Warnings.Print writes the sanitize warnings as tables to w.
+func (warnings Warnings) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	if len(warnings.NoActivity) > 0 {
+		fmt.Fprintln(tw, "Tickets without activity:")
+		fmt.Fprintln(tw, "Key\tStatus\tAssignee\tSummary")
+		for _, issue := range warnings.NoActivity {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n",
+				issue.Key, issue.Status, issue.Assignee, issue.Summary)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	if len(warnings.InvalidBooking) > 0 {
+		fmt.Fprintln(tw, "Invalid time bookings:")
+		fmt.Fprintln(tw, "Key\tActivity\tDate\tEffort\tBooked on")
+		for _, booking := range warnings.InvalidBooking {
+			for _, l := range booking.Logs {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+					booking.Issue.Key, booking.Issue.Activity,
+					l.Date, l.Effort, l.Activity)
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+
+	if len(warnings.RejectedWorkLogs) > 0 {
+		fmt.Fprintln(tw, "Rejected work logs:")
+		fmt.Fprintln(tw, "Key\tReason\tValue")
+		for _, rl := range warnings.RejectedWorkLogs {
+			fmt.Fprintf(tw, "%s\t%s\t%q\n", rl.Key, rl.Reason, rl.Value)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	if len(warnings.InvalidDates) > 0 {
+		fmt.Fprintln(tw, "Invalid dates:")
+		fmt.Fprintln(tw, "Key\tColumn\tValue")
+		for _, id := range warnings.InvalidDates {
+			fmt.Fprintf(tw, "%s\t%s\t%q\n", id.Key, id.Column, id.Value)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	fmt.Fprintf(tw, "%d warnings\n", warnings.Count)
+	tw.Flush()
+}
+
+// ResourceReport.Print writes the spend effort, the effort by type and
+// label, the average resolution effort, the lead times and the effort by
+// person as tables to w.
+func (resources ResourceReport) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "Spend effort:")
+	fmt.Fprintln(tw, "Time range\tEffort\tFTE")
+	for _, spend := range resources.Spend {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", spend.TimeRange, spend.Effort,
+			spend.FTE)
+	}
+	fmt.Fprintln(tw)
+
+	for _, groups := range resources.Usage {
+		if len(groups) == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "Effort by %s:\n", strings.ToLower(groups[0].Type))
+		fmt.Fprintf(tw, "Time range\t%s\tEffort\tFTE\t%%\n", groups[0].Type)
+		for _, group := range groups {
+			for _, details := range group.Details {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d%%\n", group.TimeRange,
+					details.Type, details.Work, details.FTE, details.Percent)
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+
+	for _, average := range resources.Average {
+		fmt.Fprintf(tw, "Average effort by ticket type (%s):\n",
+			average.TimeRange)
+		fmt.Fprintln(tw, "Type\tMedian\tMean\tCount")
+		for _, details := range average.Details {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", details.Type,
+				details.Median, details.Mean, details.Count)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	for _, flows := range resources.Flow {
+		for _, flow := range flows {
+			if len(flow.Details) == 0 {
+				continue
+			}
+			fmt.Fprintf(tw, "Lead time by %s (%s):\n",
+				strings.ToLower(flow.Group), flow.TimeRange)
+			fmt.Fprintf(tw, "%s\tCount\tMean\tMedian\t85%%\t95%%\n", flow.Group)
+			for _, details := range flow.Details {
+				fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n", details.Name,
+					details.Count, details.Mean, details.Median,
+					details.P85, details.P95)
+			}
+			fmt.Fprintln(tw)
+		}
+	}
+
+	if len(resources.People) > 0 {
+		fmt.Fprintln(tw, "Effort by person:")
+		fmt.Fprintln(tw, "Person\tTime range\tEffort\tFTE\tActivities\tTypes")
+		for _, person := range resources.People {
+			for _, r := range person.Ranges {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", person.Name,
+					r.TimeRange, r.Effort, r.FTE, joinDetails(r.Activities),
+					joinDetails(r.Types))
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+
+	for _, person := range resources.People {
+		if !person.Overbooked {
+			continue
+		}
+		fmt.Fprintf(tw, "Overbooked days of %s:\n", person.Name)
+		fmt.Fprintln(tw, "Date\tEffort")
+		for _, day := range person.Overbookings {
+			fmt.Fprintf(tw, "%s\t%s\n", day.Date, day.Effort)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	tw.Flush()
+}
+
+// ReportStatuses.Print writes the time in status statistics and the
+// reopened tickets as tables to w.
+func (statuses ReportStatuses) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	for _, st := range statuses.Types {
+		fmt.Fprintf(tw, "Time in status of %s (%d tickets", st.Type, st.Count)
+		if st.HasFlowEfficiency {
+			fmt.Fprintf(tw, ", flow efficiency %d%%", st.FlowEfficiency)
+		}
+		fmt.Fprintln(tw, "):")
+		fmt.Fprintln(tw, "Status\tCategory\tCount\tMean\tMedian")
+		for _, s := range st.Statuses {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", s.Status, s.Category,
+				s.Count, s.Mean, s.Median)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	if len(statuses.Reopened) > 0 {
+		fmt.Fprintln(tw, "Reopened tickets:")
+		fmt.Fprintln(tw, "Key\tReopens\tStatus\tAssignee\tSummary")
+		for _, issue := range statuses.Reopened {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", issue.Key, issue.Reopens,
+				issue.Status, issue.Assignee, issue.Summary)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	tw.Flush()
+}
+
+// joinDetails creates a short string representation of the details, e.g.
+// "Development 80%, Review 20%".
+func joinDetails(details []ResourceDetails) string {
+	parts := make([]string, 0)
+	for _, d := range details {
+		parts = append(parts, fmt.Sprintf("%s %d%%", d.Type, d.Percent))
+	}
+	return strings.Join(parts, ", ")
+}
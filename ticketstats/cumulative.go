@@ -0,0 +1,169 @@
+package ticketstats
+
+import (
+	"sort"
+	"time"
+)
+
+// categories are the status categories in workflow order.
+var categories = []string{CategoryTodo, CategoryInProgress, CategoryReview,
+	CategoryDone}
+
+// CumulativeFlowDay groups the number of tickets per status category at the
+// end of a day.
+type CumulativeFlowDay struct {
+	Date time.Time
+	// ticket count by status category
+	Counts map[string]int
+}
+
+// categoryChange is the change of the status category of an issue, the
+// issue is in the category from the date on.
+type categoryChange struct {
+	Date     time.Time
+	Category string
+}
+
+// Issue.categoryChanges returns the changes of the status category of the
+// issue ordered by date, starting with the category at creation, i.e. the
+// category at a time is the one of the last change not after this time.
+// With history, the categories are derived from the status changes. Without
+// history, the issue is done from the resolution date on and in the
+// category of its current status before, or todo if the issue is resolved
+// by now.
+func (issue *Issue) categoryChanges(config Config) []categoryChange {
+	changes := make([]categoryChange, 0)
+	if issue.Created.IsZero() {
+		return changes
+	}
+	add := func(date time.Time, category string) {
+		if date.Before(issue.Created) {
+			date = issue.Created
+		}
+		last := len(changes) - 1
+		if last >= 0 && changes[last].Category == category {
+			return
+		}
+		if last >= 0 && changes[last].Date.Equal(date) {
+			changes[last].Category = category
+			return
+		}
+		changes = append(changes, categoryChange{
+			Date:     date,
+			Category: category,
+		})
+	}
+
+	if len(issue.History) > 0 {
+		add(issue.Created, config.statusCategory(issue.Key,
+			issue.History[0].From))
+		for _, change := range issue.History {
+			add(change.Date, config.statusCategory(issue.Key, change.To))
+		}
+		return changes
+	}
+
+	if !issue.Resolved.IsZero() {
+		add(issue.Created, CategoryTodo)
+		add(issue.Resolved, CategoryDone)
+		return changes
+	}
+	category := config.StatusCategory(issue)
+	// closed without resolution date, the last update is the best guess
+	if category == CategoryDone {
+		add(issue.Created, CategoryTodo)
+		add(issue.Updated, CategoryDone)
+		return changes
+	}
+	add(issue.Created, category)
+	return changes
+}
+
+// CumulativeFlow counts the tickets per status category for each of the
+// given number of days, ending with now. The counts are taken at the time
+// of day of now. The category changes of each issue are added as deltas to
+// the first day after the change, so the cost is linear in the number of
+// issues.
+func CumulativeFlow(issues []*Issue, now time.Time, days int,
+	config Config) []CumulativeFlowDay {
+	result := make([]CumulativeFlowDay, 0)
+	if days <= 0 {
+		return result
+	}
+
+	dates := make([]time.Time, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		dates = append(dates, now.AddDate(0, 0, -i))
+	}
+
+	deltas := make(map[string][]int)
+	for _, category := range categories {
+		deltas[category] = make([]int, days)
+	}
+	for _, issue := range issues {
+		previous := ""
+		for _, change := range issue.categoryChanges(config) {
+			// first day not before the change
+			day := sort.Search(days, func(i int) bool {
+				return !dates[i].Before(change.Date)
+			})
+			if day < days {
+				if _, ok := deltas[change.Category]; !ok {
+					deltas[change.Category] = make([]int, days)
+				}
+				deltas[change.Category][day]++
+				if previous != "" {
+					deltas[previous][day]--
+				}
+			}
+			previous = change.Category
+		}
+	}
+
+	counts := make(map[string]int)
+	for _, category := range categories {
+		counts[category] = 0
+	}
+	for i, date := range dates {
+		day := CumulativeFlowDay{
+			Date:   date,
+			Counts: make(map[string]int),
+		}
+		for category, delta := range deltas {
+			counts[category] += delta[i]
+			day.Counts[category] = counts[category]
+		}
+		result = append(result, day)
+	}
+
+	return result
+}
+
+// CumulativeFlowByType calculates the cumulative flow for each ticket type,
+// see CumulativeFlow. Types without tickets in the time range are skipped.
+func CumulativeFlowByType(issues []*Issue, now time.Time, days int,
+	config Config) map[string][]CumulativeFlowDay {
+	result := make(map[string][]CumulativeFlowDay)
+
+	set := NewIssueSet(issues)
+	for _, issueType := range set.Types() {
+		flow := CumulativeFlow(set.ByType(issueType), now, days, config)
+		for _, day := range flow {
+			if day.total() > 0 {
+				result[issueType] = flow
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// CumulativeFlowDay.total returns the number of tickets of the day.
+func (day CumulativeFlowDay) total() int {
+	total := 0
+	for _, count := range day.Counts {
+		total += count
+	}
+	return total
+}
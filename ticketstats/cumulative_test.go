@@ -0,0 +1,179 @@
+package ticketstats
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCumulativeFlow(t *testing.T) {
+	config := DefaultConfig()
+
+	// resolved without history
+	resolved := NewIssue()
+	resolved.Key = "PRJ-1"
+	resolved.Type = "Bug"
+	resolved.Status = "Closed"
+	resolved.Created = time.Date(2021, 11, 10, 8, 0, 0, 0, time.UTC)
+	resolved.Resolved = time.Date(2021, 11, 13, 8, 0, 0, 0, time.UTC)
+
+	// in progress with history
+	active := NewIssue()
+	active.Key = "PRJ-2"
+	active.Type = "Bug"
+	active.Status = "Review"
+	active.Created = time.Date(2021, 11, 11, 8, 0, 0, 0, time.UTC)
+	active.addStatusChange(StatusChange{
+		Date: time.Date(2021, 11, 12, 8, 0, 0, 0, time.UTC),
+		From: "Open",
+		To:   "In Progress",
+	})
+	active.addStatusChange(StatusChange{
+		Date: time.Date(2021, 11, 14, 8, 0, 0, 0, time.UTC),
+		From: "In Progress",
+		To:   "Review",
+	})
+
+	// without creation date
+	unknown := NewIssue()
+	unknown.Key = "PRJ-3"
+	unknown.Type = "Feature"
+
+	flow := CumulativeFlow([]*Issue{resolved, active, unknown}, testNow, 5,
+		config)
+	if len(flow) != 5 || flow[0].Date.Day() != 11 ||
+		!flow[4].Date.Equal(testNow) {
+		log.Println("TEST: wrong days", flow)
+		t.FailNow()
+	}
+
+	expected := []map[string]int{
+		{CategoryTodo: 2},
+		{CategoryTodo: 1, CategoryInProgress: 1},
+		{CategoryInProgress: 1, CategoryDone: 1},
+		{CategoryReview: 1, CategoryDone: 1},
+		{CategoryReview: 1, CategoryDone: 1},
+	}
+	for i, counts := range expected {
+		for _, category := range categories {
+			if flow[i].Counts[category] != counts[category] {
+				log.Println("TEST: wrong counts of day", i, flow[i].Counts)
+				t.Fail()
+				break
+			}
+		}
+	}
+
+	byType := CumulativeFlowByType([]*Issue{resolved, active, unknown},
+		testNow, 5, config)
+	if len(byType) != 1 || len(byType["Bug"]) != 5 {
+		log.Println("TEST: wrong types", byType)
+		t.Fail()
+	}
+}
+
+// Issue.categoryAt returns the status category of the issue at the given
+// time, computed from the issue without categoryChanges to check them. The
+// second value is false if the issue didn't exist at this time.
+func (issue *Issue) categoryAt(t time.Time, config Config) (string, bool) {
+	if issue.Created.IsZero() || issue.Created.After(t) {
+		return "", false
+	}
+
+	if len(issue.History) > 0 {
+		status := issue.History[0].From
+		for _, change := range issue.History {
+			if change.Date.After(t) {
+				break
+			}
+			status = change.To
+		}
+		return config.statusCategory(issue.Key, status), true
+	}
+
+	if !issue.Resolved.IsZero() {
+		if issue.Resolved.After(t) {
+			return CategoryTodo, true
+		}
+		return CategoryDone, true
+	}
+	category := config.StatusCategory(issue)
+	// closed without resolution date, the last update is the best guess
+	if category == CategoryDone && issue.Updated.After(t) {
+		return CategoryTodo, true
+	}
+	return category, true
+}
+
+func TestCumulativeFlowChanges(t *testing.T) {
+	config := DefaultConfig()
+
+	issues := benchmarkIssues(300)
+	addBenchmarkHistory(issues)
+
+	days := 90
+	flow := CumulativeFlow(issues, testNow, days, config)
+	for i, day := range flow {
+		counts := make(map[string]int)
+		for _, issue := range issues {
+			category, ok := issue.categoryAt(day.Date, config)
+			if ok {
+				counts[category]++
+			}
+		}
+		for _, category := range categories {
+			if day.Counts[category] != counts[category] {
+				log.Println("TEST: wrong counts of day", i, day.Counts, counts)
+				t.FailNow()
+			}
+		}
+	}
+}
+
+func TestReportCumulativeFlow(t *testing.T) {
+	issue := NewIssue()
+	issue.Key = "PRJ-1"
+	issue.Type = "Bug"
+	issue.Status = "Open"
+	issue.Created = time.Date(2021, 11, 1, 8, 0, 0, 0, time.UTC)
+
+	config := DefaultConfig()
+	config.CumulativeFlow.Days = 3
+	report, err := GenerateReport([]*Issue{issue}, "", config,
+		Options{AsOf: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flow := report.CumulativeFlow
+	if !report.HasCumulativeFlow || len(flow.Dates) != 3 ||
+		flow.Dates[2] != "2021-11-15" || len(flow.Types) != 1 ||
+		len(flow.Types[0].Categories) != 4 ||
+		flow.Types[0].Categories[0].Counts[0] != 1 {
+		log.Println("TEST: wrong cumulative flow", flow)
+		t.FailNow()
+	}
+
+	var buf bytes.Buffer
+	err = flow.WriteCSV(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 ||
+		lines[0] != "Date;Type;todo;in progress;review;done" ||
+		lines[3] != "2021-11-15;Bug;1;0;0;0" {
+		log.Println("TEST: wrong CSV", buf.String())
+		t.Fail()
+	}
+
+	chart := string(CumulativeFlowChart(flow.Dates, flow.Types[0]))
+	if !strings.HasPrefix(chart, "<svg") ||
+		strings.Count(chart, "<polygon") != 4 ||
+		!strings.Contains(chart, "<title>todo: 1</title>") {
+		log.Println("TEST: wrong chart", chart)
+		t.Fail()
+	}
+}
@@ -0,0 +1,111 @@
+package ticketstats
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateColumns are the CSV columns containing dates, used to detect the date
+// layout of an export.
+var dateColumns = []string{"Created", "Updated", "Last Viewed", "Resolved",
+	"Due Date"}
+
+// detectRows is the number of rows checked to detect the date layout.
+const detectRows = 20
+
+// DateError describes a date of an export which couldn't be parsed.
+type DateError struct {
+	// key of the issue
+	Key string
+	// CSV column name, used for the XML and JSON exports too
+	Column string
+	// raw date value
+	Value string
+}
+
+// DateError.Error creates a string representation of the error.
+func (err *DateError) Error() string {
+	return fmt.Sprintf("invalid date of %s, column %s: %q", err.Key,
+		err.Column, err.Value)
+}
+
+// dateParser parses the dates of an export using the accepted layouts. The
+// layout in use is tried first, the dates are parsed in the configured time
+// zone.
+type dateParser struct {
+	layouts  []string
+	location *time.Location
+}
+
+// newDateParser creates a dateParser for the accepted layouts of the config.
+// If layout is not empty, it is tried first.
+func newDateParser(config Config, layout string) (*dateParser, error) {
+	location, err := config.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	layouts := make([]string, 0)
+	if layout != "" {
+		layouts = append(layouts, layout)
+	}
+	for _, l := range config.DateLayouts() {
+		if !contains(layouts, l) {
+			layouts = append(layouts, l)
+		}
+	}
+
+	return &dateParser{
+		layouts:  layouts,
+		location: location,
+	}, nil
+}
+
+// dateParser.parse parses the date using the first matching layout.
+func (parser *dateParser) parse(data string) (time.Time, error) {
+	for _, layout := range parser.layouts {
+		t, err := time.ParseInLocation(layout, data, parser.location)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no matching date layout for %q", data)
+}
+
+// detectDateLayout returns the accepted layout matching most dates of the
+// first rows of the CSV export. The header must be resolved already. An
+// empty string is returned if no date matches.
+func detectDateLayout(header []string, data [][]string, config Config) string {
+	layouts := config.DateLayouts()
+	matches := make([]int, len(layouts))
+
+	for r, row := range data {
+		if r >= detectRows {
+			break
+		}
+		for i, val := range row {
+			val = strings.TrimSpace(val)
+			if i >= len(header) || val == "" ||
+				!contains(dateColumns, header[i]) {
+				continue
+			}
+			for l, layout := range layouts {
+				_, err := time.Parse(layout, val)
+				if err == nil {
+					matches[l]++
+				}
+			}
+		}
+	}
+
+	best := ""
+	max := 0
+	for l, layout := range layouts {
+		if matches[l] > max {
+			best = layout
+			max = matches[l]
+		}
+	}
+	return best
+}
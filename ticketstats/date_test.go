@@ -0,0 +1,81 @@
+package ticketstats
+
+import (
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectDateLayout(t *testing.T) {
+	header := []string{"Issue key", "Created", "Updated"}
+	data := [][]string{
+		{"PRJ-1", "2021-08-31 12:57", "2021-09-01 08:00"},
+		{"PRJ-2", "31/Aug/21 12:57 PM", "2021-09-02 08:00"},
+	}
+
+	layout := detectDateLayout(header, data, DefaultConfig())
+	if layout != "2006-01-02 15:04" {
+		log.Println("TEST: wrong layout", layout)
+		t.Fail()
+	}
+
+	if detectDateLayout(header, [][]string{{"PRJ-1", "today", ""}},
+		DefaultConfig()) != "" {
+		log.Println("TEST: layout detected for invalid dates")
+		t.Fail()
+	}
+}
+
+func TestParseMixedDates(t *testing.T) {
+	data := "Issue key;Created;Updated;Resolved\n" +
+		"PRJ-1;2021-08-31 12:57;31/Aug/21 1:00 PM;2021-09-01T10:00:00.000+0200\n" +
+		"PRJ-2;2021-08-31 12:57;yesterday;\n"
+
+	config := DefaultConfig()
+	config.Formats.TimeZone = "Europe/Berlin"
+	issues, err := ParseCsv(strings.NewReader(data), config)
+	if err != nil || len(issues) != 2 {
+		log.Println("TEST: parse failed", err)
+		t.FailNow()
+	}
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	created := time.Date(2021, 8, 31, 12, 57, 0, 0, berlin)
+	if !issues[0].Created.Equal(created) {
+		log.Println("TEST: wrong created", issues[0].Created)
+		t.Fail()
+	}
+	if issues[0].Updated.Hour() != 13 {
+		log.Println("TEST: wrong updated", issues[0].Updated)
+		t.Fail()
+	}
+	if !issues[0].Resolved.Equal(time.Date(2021, 9, 1, 8, 0, 0, 0, time.UTC)) {
+		log.Println("TEST: wrong resolved", issues[0].Resolved)
+		t.Fail()
+	}
+
+	errs := issues[1].DateErrors
+	if len(errs) != 1 || errs[0].Key != "PRJ-2" ||
+		errs[0].Column != "Updated" || errs[0].Value != "yesterday" {
+		log.Println("TEST: wrong date errors", issues[1].ToString(config, testCalendar))
+		t.Fail()
+	}
+
+	warnings := Sanitize(issues, false, testNow, config).ToWarnings("",
+		testNow, config, testCalendar)
+	if len(warnings.InvalidDates) != 1 {
+		log.Println("TEST: invalid date not in warnings")
+		t.Fail()
+	}
+}
+
+func TestParseInvalidTimeZone(t *testing.T) {
+	config := DefaultConfig()
+	config.Formats.TimeZone = "Nowhere/City"
+	_, err := ParseCsv(strings.NewReader("Issue key\nPRJ-1\n"), config)
+	if err == nil {
+		log.Println("TEST: invalid time zone accepted")
+		t.Fail()
+	}
+}
@@ -0,0 +1,157 @@
+package ticketstats
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Value types of the generic custom fields, see config.Customs.Fields.
+const (
+	FieldString = "string"
+	FieldNumber = "number"
+	FieldDate   = "date"
+	FieldList   = "list"
+)
+
+// customFieldPrefix is the prefix of the custom field CSV columns,
+// e.g. "Custom field (Story Points)".
+const customFieldPrefix = "Custom field ("
+
+// FieldValue is the typed value of a generic custom field. Depending on
+// the type, one of the value fields is set.
+type FieldValue struct {
+	Type   string
+	String string  `json:",omitempty"`
+	Number float64 `json:",omitempty"`
+	Date   time.Time
+	List   []string `json:",omitempty"`
+}
+
+// FieldValue.ToString creates a string representation of the value.
+func (value FieldValue) ToString(config Config) string {
+	switch value.Type {
+	case FieldNumber:
+		return strconv.FormatFloat(value.Number, 'f', -1, 64)
+	case FieldDate:
+		return value.Date.Format(config.Formats.Date)
+	case FieldList:
+		return strings.Join(value.List, ", ")
+	}
+	return value.String
+}
+
+// FieldValue.Values returns the values for grouping and filtering, i.e. the
+// list entries for list fields and the string representation else.
+func (value FieldValue) Values(config Config) []string {
+	if value.Type == FieldList {
+		return value.List
+	}
+	return []string{value.ToString(config)}
+}
+
+// FieldName returns the name of a generic custom field for the CSV column
+// name, i.e. "Story Points" for "Custom field (Story Points)". Other column
+// names are used as they are.
+func FieldName(column string) string {
+	if strings.HasPrefix(column, customFieldPrefix) &&
+		strings.HasSuffix(column, ")") {
+		return column[len(customFieldPrefix) : len(column)-1]
+	}
+	return column
+}
+
+// Issue.Field returns the value of the generic custom field with the given
+// name, e.g. "Story Points".
+func (issue *Issue) Field(name string) (FieldValue, bool) {
+	value, ok := issue.Fields[name]
+	return value, ok
+}
+
+// Issue.setField converts the value of a generic custom field to the
+// configured type and sets it. The values of list fields are appended,
+// Jira exports a column for each value.
+func (issue *Issue) setField(column string, fieldType string, val string,
+	config Config) {
+	name := FieldName(column)
+	value := FieldValue{Type: fieldType}
+
+	switch fieldType {
+	case FieldNumber:
+		number, err := strconv.ParseFloat(strings.Replace(val, ",", ".", 1), 64)
+		if err != nil {
+			log.Println("ERROR: field", name, "of", issue.Key, err)
+			return
+		}
+		value.Number = number
+	case FieldDate:
+		date, err := convertFieldDate(val, config)
+		if err != nil {
+			log.Println("ERROR: field", name, "of", issue.Key, err)
+			return
+		}
+		value.Date = date
+	case FieldList:
+		value.List = issue.Fields[name].List
+		for _, v := range strings.Split(val, ",") {
+			v = strings.TrimSpace(v)
+			if v != "" {
+				value.List = append(value.List, v)
+			}
+		}
+	case FieldString, "":
+		value.Type = FieldString
+		value.String = val
+	default:
+		log.Println("ERROR: unknown type", fieldType, "of field", name)
+		return
+	}
+
+	issue.Fields[name] = value
+}
+
+// convertFieldDate converts the date of a custom field. The accepted Jira
+// export date layouts, the report date format and the Jira REST formats are
+// supported.
+func convertFieldDate(data string, config Config) (time.Time, error) {
+	location, err := config.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	layouts := append(config.DateLayouts(), config.Formats.Date,
+		jiraApiDateTime, jiraApiDate)
+	for _, layout := range layouts {
+		t, err := time.ParseInLocation(layout, data, location)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", data)
+}
+
+// FieldValues returns all values of the generic custom field with the given
+// name, e.g. to group the issues by the field.
+func FieldValues(issues []*Issue, name string, config Config) []string {
+	values := make([]string, 0)
+	for _, issue := range issues {
+		value, ok := issue.Field(name)
+		if !ok {
+			continue
+		}
+		for _, v := range value.Values(config) {
+			if !contains(values, v) {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// FilterByField reduces the issues to the ones having the given value for
+// the generic custom field. For list fields, one of the values must match.
+func FilterByField(issues []*Issue, name string, value string,
+	config Config) []*Issue {
+	return Filter(issues, ByField(name, value, config))
+}
@@ -0,0 +1,82 @@
+package ticketstats
+
+import (
+	"log"
+	"testing"
+	"time"
+)
+
+func TestFieldName(t *testing.T) {
+	if FieldName("Custom field (Story Points)") != "Story Points" ||
+		FieldName("Team") != "Team" {
+		log.Println("TEST: wrong field names")
+		t.Fail()
+	}
+}
+
+func TestSetField(t *testing.T) {
+	config := DefaultConfig()
+	config.Customs.Fields["Custom field (Story Points)"] = FieldNumber
+	config.Customs.Fields["Custom field (Team)"] = FieldString
+	config.Customs.Fields["Custom field (Customer)"] = FieldList
+	config.Customs.Fields["Custom field (Start)"] = FieldDate
+
+	issue := NewIssue()
+	issue.setCustomField("Custom field (Story Points)", "2,5", config)
+	issue.setCustomField("Custom field (Team)", "Blue", config)
+	issue.setCustomField("Custom field (Customer)", "A", config)
+	issue.setCustomField("Custom field (Customer)", "B, C", config)
+	issue.setCustomField("Custom field (Start)", "01/Nov/21 9:00 AM", config)
+	issue.setCustomField("Custom field (Unknown)", "X", config)
+
+	if value, ok := issue.Field("Story Points"); !ok || value.Number != 2.5 {
+		log.Println("TEST: wrong number", value)
+		t.Fail()
+	}
+	if value, ok := issue.Field("Team"); !ok || value.String != "Blue" {
+		log.Println("TEST: wrong string", value)
+		t.Fail()
+	}
+	if value, ok := issue.Field("Customer"); !ok || len(value.List) != 3 ||
+		value.ToString(config) != "A, B, C" {
+		log.Println("TEST: wrong list", value)
+		t.Fail()
+	}
+	start := time.Date(2021, 11, 1, 9, 0, 0, 0, time.UTC)
+	if value, ok := issue.Field("Start"); !ok || !value.Date.Equal(start) {
+		log.Println("TEST: wrong date", value)
+		t.Fail()
+	}
+	if _, ok := issue.Field("Unknown"); ok || len(issue.Fields) != 4 {
+		log.Println("TEST: unknown field set")
+		t.Fail()
+	}
+
+	issue.setCustomField("Custom field (Story Points)", "many", config)
+	if value, _ := issue.Field("Story Points"); value.Number != 2.5 {
+		log.Println("TEST: invalid number set", value)
+		t.Fail()
+	}
+}
+
+func TestFilterByField(t *testing.T) {
+	config := DefaultConfig()
+
+	issue := func(customers ...string) *Issue {
+		issue := NewIssue()
+		issue.Fields["Customer"] = FieldValue{Type: FieldList, List: customers}
+		return issue
+	}
+	issues := []*Issue{issue("A", "B"), issue("B"), issue(), NewIssue()}
+
+	values := FieldValues(issues, "Customer", config)
+	if len(values) != 2 || values[0] != "A" || values[1] != "B" {
+		log.Println("TEST: wrong values", values)
+		t.Fail()
+	}
+	if len(FilterByField(issues, "Customer", "B", config)) != 2 ||
+		len(FilterByField(issues, "Customer", "A", config)) != 1 {
+		log.Println("TEST: wrong filter result")
+		t.Fail()
+	}
+}
@@ -6,28 +6,22 @@ import (
 	"time"
 )
 
-// ActiveTickets returns all active tickets.
-func ActiveTickets(issues []*Issue, config Config) []*Issue {
-	noDate := time.Time{}
-	return Filter(issues, func(issue *Issue) bool {
-		return (issue.Resolved == noDate &&
-			issue.Status != config.States.Closed) ||
-			lastMonth(issue.Updated)
-	})
+// ActiveTickets returns all active tickets, i.e. all open tickets and all
+// tickets updated during the month before now.
+func ActiveTickets(issues []*Issue, now time.Time, config Config) []*Issue {
+	return Filter(issues, IsActive(now, config))
 }
 
-// OpenTickets returns all tickets with no resolution date and state not close.
+// OpenTickets returns all tickets with no resolution date and a state not in
+// the category done.
 func OpenTickets(issues []*Issue, config Config) []*Issue {
-	noDate := time.Time{}
-	return Filter(issues, func(issue *Issue) bool {
-		return issue.Resolved == noDate &&
-			issue.Status != config.States.Closed
-	})
+	return Filter(issues, IsOpen(config))
 }
 
 // Filter filters the given issue list using the given test function.
 // A issue is part of the returned list if the test function returns true.
-func Filter(issues []*Issue, test func(issue *Issue) bool) []*Issue {
+// Combined predicates, see Predicate, are evaluated in a single pass.
+func Filter(issues []*Issue, test Predicate) []*Issue {
 	result := make([]*Issue, 0)
 
 	for _, issue := range issues {
@@ -107,6 +101,24 @@ func Labels(issues []*Issue) []string {
 	return result
 }
 
+// Authors returns a list of all work log authors of the given tickets.
+func Authors(issues []*Issue) []string {
+	result := make([]string, 0)
+	authors := make(map[string]int)
+
+	for _, issue := range issues {
+		for _, log := range issue.LogWorks {
+			_, ok := authors[log.Author]
+			if !ok && log.Author != "" {
+				authors[log.Author] = 1
+				result = append(result, log.Author)
+			}
+		}
+	}
+
+	return result
+}
+
 // Components returns a list of all components assigned to the given tickets.
 func Components(issues []*Issue) []string {
 	result := make([]string, 0)
@@ -127,128 +139,111 @@ func Components(issues []*Issue) []string {
 
 // contains is a helper to check if a string is contained in a string list.
 func contains(slice []string, item string) bool {
-	set := make(map[string]struct{}, len(slice))
 	for _, s := range slice {
-		set[s] = struct{}{}
+		if s == item {
+			return true
+		}
 	}
-
-	_, ok := set[item]
-	return ok
+	return false
 }
 
 // FilterByFixVersion reduces the given list to contain only tickets with the
 // given fix version.
 func FilterByFixVersion(issues []*Issue, fixVersion string) []*Issue {
-	return Filter(issues, func(issue *Issue) bool {
-		return contains(issue.FixVersions, fixVersion)
-	})
+	return Filter(issues, ByFixVersion(fixVersion))
 }
 
 // FilterBySecurityLevel reduces the given list to contain only tickets with the
 // given security level.
 func FilterBySecurityLevel(issues []*Issue, securityLevel string) []*Issue {
-	return Filter(issues, func(issue *Issue) bool {
-		return issue.SecurityLevel == securityLevel
-	})
+	return Filter(issues, BySecurityLevel(securityLevel))
 }
 
 // FilterByPriority reduces the given list to contain only tickets with the
 // given priority.
 func FilterByPriority(issues []*Issue, priority string) []*Issue {
-	return Filter(issues, func(issue *Issue) bool {
-		return issue.Priority == priority
-	})
+	return Filter(issues, ByPriority(priority))
 }
 
 // FilterByProject only returns the tickets matching the given project key.
 func FilterByProject(issues []*Issue, project string) []*Issue {
-	return Filter(issues, func(issue *Issue) bool {
-		return strings.HasPrefix(issue.Key, project)
-	})
+	return Filter(issues, ByProject(project))
 }
 
 // CreatedLastWeek returns all issues created during the last 7 days.
-func CreatedLastWeek(issues []*Issue) []*Issue {
+func CreatedLastWeek(issues []*Issue, now time.Time) []*Issue {
 	return Filter(issues, func(issue *Issue) bool {
-		return lastWeek(issue.Created)
+		return lastWeek(issue.Created, now)
 	})
 }
 
 // CreatedLastMonth returns all issues created during the last month.
-func CreatedLastMonth(issues []*Issue) []*Issue {
+func CreatedLastMonth(issues []*Issue, now time.Time) []*Issue {
 	return Filter(issues, func(issue *Issue) bool {
-		return lastMonth(issue.Created)
+		return lastMonth(issue.Created, now)
 	})
 }
 
 // CreatedLastQuarter returns all issues created during the last three month.
-func CreatedLastQuarter(issues []*Issue) []*Issue {
+func CreatedLastQuarter(issues []*Issue, now time.Time) []*Issue {
 	return Filter(issues, func(issue *Issue) bool {
-		return lastQuarter(issue.Created)
+		return lastQuarter(issue.Created, now)
 	})
 }
 
 // CreatedLastYear returns all issues created during the last year
-func CreatedLastYear(issues []*Issue) []*Issue {
+func CreatedLastYear(issues []*Issue, now time.Time) []*Issue {
 	return Filter(issues, func(issue *Issue) bool {
-		return lastYear(issue.Created)
+		return lastYear(issue.Created, now)
 	})
 }
 
 // ClosedLastWeek returns all issues resolved during the last week.
-func ClosedLastWeek(issues []*Issue) []*Issue {
+func ClosedLastWeek(issues []*Issue, now time.Time) []*Issue {
 	return Filter(issues, func(issue *Issue) bool {
-		return lastWeek(issue.Resolved)
+		return lastWeek(issue.Resolved, now)
 	})
 }
 
 // ClosedLastMonth returns all issues resolved during the last month.
-func ClosedLastMonth(issues []*Issue) []*Issue {
+func ClosedLastMonth(issues []*Issue, now time.Time) []*Issue {
 	return Filter(issues, func(issue *Issue) bool {
-		return lastMonth(issue.Resolved)
+		return lastMonth(issue.Resolved, now)
 	})
 }
 
 // ClosedLastQuarter returns all issues resolved during the last three month.
-func ClosedLastQuarter(issues []*Issue) []*Issue {
+func ClosedLastQuarter(issues []*Issue, now time.Time) []*Issue {
 	return Filter(issues, func(issue *Issue) bool {
-		return lastQuarter(issue.Resolved)
+		return lastQuarter(issue.Resolved, now)
 	})
 }
 
 // ClosedLastYear returns all issues resolved during the last year.
-func ClosedLastYear(issues []*Issue) []*Issue {
+func ClosedLastYear(issues []*Issue, now time.Time) []*Issue {
 	return Filter(issues, func(issue *Issue) bool {
-		return lastYear(issue.Resolved)
+		return lastYear(issue.Resolved, now)
 	})
 }
 
 // OlderThanOneMonth returns all tickets older than one month.
-func OlderThanOneMonth(issues []*Issue) []*Issue {
-	lm := time.Now().AddDate(0, -1, 0)
-	return Filter(issues, func(issue *Issue) bool {
-		return issue.Created.Before(lm)
-	})
+func OlderThanOneMonth(issues []*Issue, now time.Time) []*Issue {
+	return Filter(issues, CreatedBefore(now.AddDate(0, -1, 0)))
 }
 
 // FilterByType returns all issues matching the given type.
 func FilterByType(issues []*Issue, ticketType string) []*Issue {
-	return Filter(issues, func(issue *Issue) bool {
-		return issue.Type == ticketType
-	})
+	return Filter(issues, ByType(ticketType))
 }
 
+// FilterByLabel returns all issues with the given label.
 func FilterByLabel(issues []*Issue, label string) []*Issue {
-	return Filter(issues, func(issue *Issue) bool {
-		return contains(issue.Labels, label)
-	})
+	return Filter(issues, ByLabel(label))
 }
 
 // FilterByComponent returns all issues matching the given component.
 func FilterByComponent(issues []*Issue, component string) []*Issue {
-	return Filter(issues, func(issue *Issue) bool {
-		return contains(issue.Components, component)
-	})
+	return Filter(issues, ByComponent(component))
 }
 
 // OrderByCreated orders the issues by created date.
@@ -280,8 +275,8 @@ func OrderByStatus(issues []*Issue) {
 }
 
 // Age returns the difference from now to the given date as days.
-func Age(date time.Time) int {
-	diff := time.Since(date)
+func Age(date time.Time, now time.Time) int {
+	diff := now.Sub(date)
 	return int(diff.Hours() / 24)
 }
 
@@ -303,26 +298,26 @@ func IssuesByComponent(issues []*Issue) map[string][]*Issue {
 	return components
 }
 
-// lastWeek creates a date 7 days back from now.
-func lastWeek(date time.Time) bool {
-	lm := time.Now().AddDate(0, 0, -7)
-	return date.After(lm)
+// lastWeek tests if the date is within the 7 days before now.
+func lastWeek(date time.Time, now time.Time) bool {
+	lm := now.AddDate(0, 0, -7)
+	return date.After(lm) && !date.After(now)
 }
 
-// lastMonth creates a date one month back from now.
-func lastMonth(date time.Time) bool {
-	lm := time.Now().AddDate(0, -1, 0)
-	return date.After(lm)
+// lastMonth tests if the date is within the month before now.
+func lastMonth(date time.Time, now time.Time) bool {
+	lm := now.AddDate(0, -1, 0)
+	return date.After(lm) && !date.After(now)
 }
 
-// lastQuarter creates a date three month back from now.
-func lastQuarter(date time.Time) bool {
-	lm := time.Now().AddDate(0, -3, 0)
-	return date.After(lm)
+// lastQuarter tests if the date is within the three month before now.
+func lastQuarter(date time.Time, now time.Time) bool {
+	lm := now.AddDate(0, -3, 0)
+	return date.After(lm) && !date.After(now)
 }
 
-// lastYear creates a date one year back from now.
-func lastYear(date time.Time) bool {
-	lm := time.Now().AddDate(-1, 0, 0)
-	return date.After(lm)
+// lastYear tests if the date is within the year before now.
+func lastYear(date time.Time, now time.Time) bool {
+	lm := now.AddDate(-1, 0, 0)
+	return date.After(lm) && !date.After(now)
 }
@@ -4,7 +4,6 @@ import (
 	"log"
 	"strings"
 	"testing"
-	"time"
 )
 
 func TestActiveTickets(t *testing.T) {
@@ -13,8 +12,8 @@ func TestActiveTickets(t *testing.T) {
 	// issue which was updated recently
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Resolved = time.Now().AddDate(0, 0, -5)
-	issue.Updated = time.Now()
+	issue.Resolved = testNow.AddDate(0, 0, -5)
+	issue.Updated = testNow
 	issues = append(issues, issue)
 
 	// issue which was not resolved
@@ -26,12 +25,12 @@ func TestActiveTickets(t *testing.T) {
 	// issue which was resolved a while ago and not recently updated
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Resolved = time.Now().AddDate(0, -2, 0)
-	issue.Updated = time.Now().AddDate(0, -1, -10)
+	issue.Resolved = testNow.AddDate(0, -2, 0)
+	issue.Updated = testNow.AddDate(0, -1, -10)
 	issues = append(issues, issue)
 
 	// call function to test
-	issues = ActiveTickets(issues, DefaultConfig())
+	issues = ActiveTickets(issues, testNow, DefaultConfig())
 
 	if len(issues) != 2 {
 		log.Println("TEST: issue count not expected")
@@ -58,8 +57,8 @@ func TestOpenTickets(t *testing.T) {
 
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Resolved = time.Now().AddDate(0, 0, -5)
-	issue.Updated = time.Now()
+	issue.Resolved = testNow.AddDate(0, 0, -5)
+	issue.Updated = testNow
 	issues = append(issues, issue)
 
 	issue = NewIssue()
@@ -68,8 +67,8 @@ func TestOpenTickets(t *testing.T) {
 
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Resolved = time.Now().AddDate(0, -2, 0)
-	issue.Updated = time.Now().AddDate(0, -1, -10)
+	issue.Resolved = testNow.AddDate(0, -2, 0)
+	issue.Updated = testNow.AddDate(0, -1, -10)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
@@ -378,21 +377,21 @@ func TestCreatedLastWeek(t *testing.T) {
 
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Created = time.Now().AddDate(0, -1, 0)
+	issue.Created = testNow.AddDate(0, -1, 0)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
-	issue.Created = time.Now().AddDate(0, 0, -8)
+	issue.Created = testNow.AddDate(0, 0, -8)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Created = time.Now().AddDate(0, 0, -5)
+	issue.Created = testNow.AddDate(0, 0, -5)
 	issues = append(issues, issue)
 
 	// call function to test
-	issues = CreatedLastWeek(issues)
+	issues = CreatedLastWeek(issues, testNow)
 
 	if len(issues) != 1 {
 		log.Println("TEST: issue count not expected")
@@ -410,21 +409,21 @@ func TestCreatedLastMonth(t *testing.T) {
 
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Created = time.Now().AddDate(0, -1, 0)
+	issue.Created = testNow.AddDate(0, -1, 0)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
-	issue.Created = time.Now().AddDate(0, -2, -8)
+	issue.Created = testNow.AddDate(0, -2, -8)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Created = time.Now().AddDate(0, 0, -20)
+	issue.Created = testNow.AddDate(0, 0, -20)
 	issues = append(issues, issue)
 
 	// call function to test
-	issues = CreatedLastMonth(issues)
+	issues = CreatedLastMonth(issues, testNow)
 
 	if len(issues) != 1 {
 		log.Println("TEST: issue count not expected")
@@ -442,20 +441,20 @@ func TestCreatedLastQuarter(t *testing.T) {
 
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Created = time.Now().AddDate(0, -3, -2)
+	issue.Created = testNow.AddDate(0, -3, -2)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
-	issue.Created = time.Now().AddDate(0, -5, -8)
+	issue.Created = testNow.AddDate(0, -5, -8)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Created = time.Now().AddDate(0, -2, -5)
+	issue.Created = testNow.AddDate(0, -2, -5)
 	issues = append(issues, issue)
 
-	issues = CreatedLastQuarter(issues)
+	issues = CreatedLastQuarter(issues, testNow)
 
 	if len(issues) != 1 {
 		log.Println("TEST: issue count not expected")
@@ -473,21 +472,21 @@ func TestCreatedLastYear(t *testing.T) {
 
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Created = time.Now().AddDate(-1, 0, -2)
+	issue.Created = testNow.AddDate(-1, 0, -2)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
-	issue.Created = time.Now().AddDate(-1, -5, -8)
+	issue.Created = testNow.AddDate(-1, -5, -8)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Created = time.Now().AddDate(0, -10, -5)
+	issue.Created = testNow.AddDate(0, -10, -5)
 	issues = append(issues, issue)
 
 	// call function to test
-	issues = CreatedLastYear(issues)
+	issues = CreatedLastYear(issues, testNow)
 
 	if len(issues) != 1 {
 		log.Println("TEST: issue count not expected")
@@ -505,21 +504,21 @@ func TestClosedLastWeek(t *testing.T) {
 
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Resolved = time.Now().AddDate(0, -1, 0)
+	issue.Resolved = testNow.AddDate(0, -1, 0)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
-	issue.Resolved = time.Now().AddDate(0, 0, -8)
+	issue.Resolved = testNow.AddDate(0, 0, -8)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Resolved = time.Now().AddDate(0, 0, -5)
+	issue.Resolved = testNow.AddDate(0, 0, -5)
 	issues = append(issues, issue)
 
 	// call function to test
-	issues = ClosedLastWeek(issues)
+	issues = ClosedLastWeek(issues, testNow)
 
 	if len(issues) != 1 {
 		log.Println("TEST: issue count not expected")
@@ -537,21 +536,21 @@ func TestClosedLastMonth(t *testing.T) {
 
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Resolved = time.Now().AddDate(0, -1, 0)
+	issue.Resolved = testNow.AddDate(0, -1, 0)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
-	issue.Resolved = time.Now().AddDate(0, -2, -8)
+	issue.Resolved = testNow.AddDate(0, -2, -8)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Resolved = time.Now().AddDate(0, 0, -20)
+	issue.Resolved = testNow.AddDate(0, 0, -20)
 	issues = append(issues, issue)
 
 	// call function to test
-	issues = ClosedLastMonth(issues)
+	issues = ClosedLastMonth(issues, testNow)
 
 	if len(issues) != 1 {
 		log.Println("TEST: issue count not expected")
@@ -569,21 +568,21 @@ func TestClosedLastQuarter(t *testing.T) {
 
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Resolved = time.Now().AddDate(0, -3, -2)
+	issue.Resolved = testNow.AddDate(0, -3, -2)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
-	issue.Resolved = time.Now().AddDate(0, -5, -8)
+	issue.Resolved = testNow.AddDate(0, -5, -8)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Resolved = time.Now().AddDate(0, -2, -5)
+	issue.Resolved = testNow.AddDate(0, -2, -5)
 	issues = append(issues, issue)
 
 	// call function to test
-	issues = ClosedLastQuarter(issues)
+	issues = ClosedLastQuarter(issues, testNow)
 
 	if len(issues) != 1 {
 		log.Println("TEST: issue count not expected")
@@ -601,21 +600,21 @@ func TestClosedLastYear(t *testing.T) {
 
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Resolved = time.Now().AddDate(-1, 0, -2)
+	issue.Resolved = testNow.AddDate(-1, 0, -2)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
-	issue.Resolved = time.Now().AddDate(-1, -5, -8)
+	issue.Resolved = testNow.AddDate(-1, -5, -8)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Resolved = time.Now().AddDate(0, -10, -5)
+	issue.Resolved = testNow.AddDate(0, -10, -5)
 	issues = append(issues, issue)
 
 	// call function to test
-	issues = ClosedLastYear(issues)
+	issues = ClosedLastYear(issues, testNow)
 
 	if len(issues) != 1 {
 		log.Println("TEST: issue count not expected")
@@ -633,21 +632,21 @@ func TestOlderThanOneMonth(t *testing.T) {
 
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Created = time.Now().AddDate(0, -1, -2)
+	issue.Created = testNow.AddDate(0, -1, -2)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
-	issue.Created = time.Now().AddDate(-1, 0, 0)
+	issue.Created = testNow.AddDate(-1, 0, 0)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Created = time.Now().AddDate(0, 0, -25)
+	issue.Created = testNow.AddDate(0, 0, -25)
 	issues = append(issues, issue)
 
 	// call function to test
-	issues = OlderThanOneMonth(issues)
+	issues = OlderThanOneMonth(issues, testNow)
 
 	if len(issues) != 2 {
 		log.Println("TEST: issue count not expected")
@@ -790,17 +789,17 @@ func TestOrderByCreated(t *testing.T) {
 
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Created = time.Now().AddDate(0, -1, 0)
+	issue.Created = testNow.AddDate(0, -1, 0)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
-	issue.Created = time.Now().AddDate(0, -1, -1)
+	issue.Created = testNow.AddDate(0, -1, -1)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Created = time.Now().AddDate(0, 0, -20)
+	issue.Created = testNow.AddDate(0, 0, -20)
 	issues = append(issues, issue)
 
 	// call function to test
@@ -822,17 +821,17 @@ func TestOrderByDue(t *testing.T) {
 
 	issue := NewIssue()
 	issue.Key = "A"
-	issue.Due = time.Now().AddDate(0, -1, 0)
+	issue.Due = testNow.AddDate(0, -1, 0)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
-	issue.Due = time.Now().AddDate(0, -1, -1)
+	issue.Due = testNow.AddDate(0, -1, -1)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "C"
-	issue.Due = time.Now().AddDate(0, 0, -20)
+	issue.Due = testNow.AddDate(0, 0, -20)
 	issues = append(issues, issue)
 
 	OrderByDue(issues)
@@ -911,7 +910,7 @@ func TestOrderByStatus(t *testing.T) {
 }
 
 func TestAge(t *testing.T) {
-	if Age(time.Now().AddDate(0, 0, -23)) != 23 {
+	if Age(testNow.AddDate(0, 0, -23), testNow) != 23 {
 		log.Println("TEST: wrong number of days")
 		t.Fail()
 	}
@@ -972,49 +971,70 @@ func TestIssuesByComponent(t *testing.T) {
 }
 
 func TestLastWeek(t *testing.T) {
-	if !lastWeek(time.Now().AddDate(0, 0, -5)) {
+	if !lastWeek(testNow.AddDate(0, 0, -5), testNow) {
 		log.Println("TEST: date within last week")
 		t.Fail()
 	}
 
-	if lastWeek(time.Now().AddDate(0, 0, -10)) {
+	if lastWeek(testNow.AddDate(0, 0, -10), testNow) {
 		log.Println("TEST: date not within last week")
 		t.Fail()
 	}
 }
 
 func TestLastMonth(t *testing.T) {
-	if !lastMonth(time.Now().AddDate(0, 0, -25)) {
+	if !lastMonth(testNow.AddDate(0, 0, -25), testNow) {
 		log.Println("TEST: date within last month")
 		t.Fail()
 	}
 
-	if lastMonth(time.Now().AddDate(0, -1, -1)) {
+	if lastMonth(testNow.AddDate(0, -1, -1), testNow) {
 		log.Println("TEST: date not within last month")
 		t.Fail()
 	}
 }
 
 func TestLastQuarter(t *testing.T) {
-	if !lastQuarter(time.Now().AddDate(0, -2, -25)) {
+	if !lastQuarter(testNow.AddDate(0, -2, -25), testNow) {
 		log.Println("TEST: date within last quarter")
 		t.Fail()
 	}
 
-	if lastQuarter(time.Now().AddDate(0, -3, -1)) {
+	if lastQuarter(testNow.AddDate(0, -3, -1), testNow) {
 		log.Println("TEST: date not within last quarter")
 		t.Fail()
 	}
 }
 
 func TestLastYear(t *testing.T) {
-	if !lastYear(time.Now().AddDate(0, -11, -25)) {
+	if !lastYear(testNow.AddDate(0, -11, -25), testNow) {
 		log.Println("TEST: date within last year")
 		t.Fail()
 	}
 
-	if lastYear(time.Now().AddDate(-1, 0, -1)) {
+	if lastYear(testNow.AddDate(-1, 0, -1), testNow) {
 		log.Println("TEST: date not within last year")
 		t.Fail()
 	}
 }
+
+func TestWindowsEndAtNow(t *testing.T) {
+	later := testNow.AddDate(0, 0, 1)
+	if lastWeek(later, testNow) ||
+		lastMonth(later, testNow) ||
+		lastQuarter(later, testNow) ||
+		lastYear(later, testNow) {
+		log.Println("TEST: date after reference time in window")
+		t.Fail()
+	}
+
+	issue := NewIssue()
+	issue.Created = testNow.AddDate(0, 0, -3)
+	issues := []*Issue{issue}
+
+	if len(CreatedLastWeek(issues, testNow)) != 1 ||
+		len(CreatedLastWeek(issues, testNow.AddDate(0, 0, -4))) != 0 {
+		log.Println("TEST: window not relative to reference time")
+		t.Fail()
+	}
+}
@@ -0,0 +1,107 @@
+package ticketstats
+
+import (
+	"fmt"
+	"strings"
+)
+
+// requiredColumns are the CSV columns needed to identify the issues.
+var requiredColumns = []string{"Issue key"}
+
+// headerPresets are the built-in aliases of the CSV column names. Each
+// preset maps the column names of a Jira language to the English column
+// names used by Parse. Aliases ending with "(" are prefixes, e.g. for the
+// custom field columns.
+var headerPresets = map[string]map[string]string{
+	"en": {
+		// Jira Cloud column names
+		"Affects versions": "Affects Version/s",
+		"Fix versions":     "Fix Version/s",
+		"Components":       "Component/s",
+		"Due date":         "Due Date",
+		"Parent":           "Parent id",
+	},
+	"de": {
+		"Zusammenfassung":            "Summary",
+		"Schlüssel":                  "Issue key",
+		"Vorgangsschlüssel":          "Issue key",
+		"Vorgangs-ID":                "Issue id",
+		"Übergeordnete ID":           "Parent id",
+		"Vorgangstyp":                "Issue Type",
+		"Status":                     "Status",
+		"Priorität":                  "Priority",
+		"Bearbeiter":                 "Assignee",
+		"Zugewiesene Person":         "Assignee",
+		"Ersteller":                  "Creator",
+		"Erstellt":                   "Created",
+		"Aktualisiert":               "Updated",
+		"Zuletzt angesehen":          "Last Viewed",
+		"Betroffene Version/en":      "Affects Version/s",
+		"Lösungsversion/en":          "Fix Version/s",
+		"Komponente/n":               "Component/s",
+		"Arbeit protokollieren":      "Log Work",
+		"Ursprüngliche Schätzung":    "Original Estimate",
+		"Verbleibende Schätzung":     "Remaining Estimate",
+		"Benötigte Zeit":             "Time Spent",
+		"Σ Ursprüngliche Schätzung":  "Σ Original Estimate",
+		"Σ Verbleibende Schätzung":   "Σ Remaining Estimate",
+		"Σ Benötigte Zeit":           "Σ Time Spent",
+		"Sicherheitsstufe":           "Security Level",
+		"Stichwörter":                "Labels",
+		"Lösung":                     "Resolution",
+		"Erledigt":                   "Resolved",
+		"Fälligkeitsdatum":           "Due Date",
+		"Ausgehender Link (":         outwardLinkPrefix,
+		"Eingehender Link (":         inwardLinkPrefix,
+		"Benutzerdefiniertes Feld (": customFieldPrefix,
+	},
+}
+
+// resolveHeader maps the CSV column names to the English column names
+// used by Parse, using the aliases of config.Headers and the preset of
+// config.Headers.Language. An error is returned if the language is unknown
+// or a required column is missing.
+func resolveHeader(header []string, config Config) ([]string, error) {
+	language := config.Headers.Language
+	if language == "" {
+		language = "en"
+	}
+	preset, ok := headerPresets[language]
+	if !ok {
+		return nil, fmt.Errorf("unknown header language %q", language)
+	}
+
+	columns := make([]string, 0)
+	for _, column := range header {
+		column = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		alias, ok := resolveAlias(column, config.Headers.Aliases)
+		if !ok {
+			alias, _ = resolveAlias(column, preset)
+		}
+		columns = append(columns, alias)
+	}
+
+	for _, required := range requiredColumns {
+		if !contains(columns, required) {
+			return nil, fmt.Errorf("CSV export without column %q, "+
+				"check config.Headers", required)
+		}
+	}
+
+	return columns, nil
+}
+
+// resolveAlias maps a column name using the aliases. Prefix aliases, which
+// end with "(", replace the prefix of the column name. Columns without
+// alias are returned as they are.
+func resolveAlias(column string, aliases map[string]string) (string, bool) {
+	if alias, ok := aliases[column]; ok {
+		return alias, true
+	}
+	for prefix, alias := range aliases {
+		if strings.HasSuffix(prefix, "(") && strings.HasPrefix(column, prefix) {
+			return alias + column[len(prefix):], true
+		}
+	}
+	return column, false
+}
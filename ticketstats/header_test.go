@@ -0,0 +1,60 @@
+package ticketstats
+
+import (
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestParseGermanHeader(t *testing.T) {
+	config := DefaultConfig()
+	config.Headers.Language = "de"
+
+	data := "\ufeffZusammenfassung;Schlüssel;Vorgangstyp;Lösungsversion/en;" +
+		"Lösungsversion/en;Ausgehender Link (Blocks);" +
+		"Benutzerdefiniertes Feld (External ID)\n" +
+		"Ein Fehler;PRJ-1;Bug;1.0;2.0;PRJ-2;EXT-1\n"
+
+	issues, err := ParseCsv(strings.NewReader(data), config)
+	if err != nil || len(issues) != 1 {
+		log.Println("TEST: german export not parsed", err)
+		t.FailNow()
+	}
+	issue := issues[0]
+	if issue.Summary != "Ein Fehler" || issue.Key != "PRJ-1" ||
+		issue.Type != "Bug" || len(issue.FixVersions) != 2 {
+		log.Println("TEST: wrong issue", issue.ToString(config, testCalendar))
+		t.Fail()
+	}
+	if len(issue.LinkBlocks) != 1 || issue.CustomExternalId != "EXT-1" {
+		log.Println("TEST: wrong prefix columns", issue.ToString(config, testCalendar))
+		t.Fail()
+	}
+}
+
+func TestHeaderAliases(t *testing.T) {
+	config := DefaultConfig()
+	config.Headers.Aliases["Ticket"] = "Issue key"
+	config.Headers.Aliases["Titel"] = "Summary"
+
+	header, err := resolveHeader([]string{"Ticket", "Titel", "Status"}, config)
+	if err != nil || header[0] != "Issue key" || header[1] != "Summary" ||
+		header[2] != "Status" {
+		log.Println("TEST: wrong aliases", header, err)
+		t.Fail()
+	}
+
+	_, err = resolveHeader([]string{"Zusammenfassung", "Schlüssel"},
+		DefaultConfig())
+	if err == nil || !strings.Contains(err.Error(), "Issue key") {
+		log.Println("TEST: missing issue key without error", err)
+		t.Fail()
+	}
+
+	config.Headers.Language = "fr"
+	_, err = resolveHeader([]string{"Issue key"}, config)
+	if err == nil {
+		log.Println("TEST: unknown language without error")
+		t.Fail()
+	}
+}
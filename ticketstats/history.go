@@ -0,0 +1,288 @@
+package ticketstats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// historySize is the number of snapshots shown in the history report.
+const historySize = 12
+
+// snapshotTimeFormat is used for the snapshot file names.
+const snapshotTimeFormat = "20060102T150405"
+
+// Snapshot groups the issues and the computed report of one program run.
+type Snapshot struct {
+	Time      time.Time
+	Component string
+	Report    Report
+	Issues    []*Issue
+}
+
+// snapshotHeader is the first line of a snapshot file.
+type snapshotHeader struct {
+	Time      time.Time
+	Component string
+	Report    Report
+}
+
+// SnapshotStore is a JSON lines archive of snapshots.
+// Each snapshot is stored as one file in the store directory. The first
+// line of a file contains the time, the component and the report, each
+// following line contains one issue.
+type SnapshotStore struct {
+	Dir string
+}
+
+// NewSnapshotStore creates a new SnapshotStore using the given directory.
+func NewSnapshotStore(dir string) *SnapshotStore {
+	return &SnapshotStore{
+		Dir: dir,
+	}
+}
+
+// snapshotComponent replaces the characters of the component which are
+// not allowed in file names.
+func snapshotComponent(component string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, component)
+}
+
+// snapshotFileName creates the file name for a snapshot.
+// The name starts with the time to keep the files ordered, followed by the
+// component.
+func snapshotFileName(snapshot Snapshot) string {
+	return snapshot.Time.UTC().Format(snapshotTimeFormat) + "_" +
+		snapshotComponent(snapshot.Component) + ".jsonl"
+}
+
+// isSnapshotFileName checks if name is the file name of a snapshot of the
+// given component.
+func isSnapshotFileName(name string, component string) bool {
+	if !strings.HasSuffix(name, ".jsonl") ||
+		len(name) < len(snapshotTimeFormat)+1 {
+		return false
+	}
+	_, err := time.Parse(snapshotTimeFormat, name[:len(snapshotTimeFormat)])
+	if err != nil {
+		return false
+	}
+	return strings.TrimSuffix(name[len(snapshotTimeFormat):], ".jsonl") ==
+		"_"+snapshotComponent(component)
+}
+
+// SnapshotStore.Save writes the snapshot to the store.
+func (store *SnapshotStore) Save(snapshot Snapshot) error {
+	err := os.MkdirAll(store.Dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(store.Dir, snapshotFileName(snapshot))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	err = enc.Encode(snapshotHeader{
+		Time:      snapshot.Time,
+		Component: snapshot.Component,
+		Report:    snapshot.Report,
+	})
+	if err != nil {
+		return err
+	}
+	for _, issue := range snapshot.Issues {
+		err = enc.Encode(issue)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// SnapshotStore.Load loads the latest snapshots of the given component
+// taken before the given time, e.g. the reference time of a report. At most
+// count snapshots are returned, ordered from old to new. Only the files of
+// the component are read, unreadable files are skipped.
+func (store *SnapshotStore) Load(component string, before time.Time,
+	count int) ([]Snapshot, error) {
+	snapshots := make([]Snapshot, 0)
+
+	files, err := ioutil.ReadDir(store.Dir)
+	if os.IsNotExist(err) {
+		return snapshots, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	for _, file := range files {
+		if file.IsDir() || !isSnapshotFileName(file.Name(), component) {
+			continue
+		}
+		// the file name contains the time without fractions of a second
+		taken, _ := time.Parse(snapshotTimeFormat,
+			file.Name()[:len(snapshotTimeFormat)])
+		if taken.After(before) {
+			continue
+		}
+		names = append(names, file.Name())
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	for _, name := range names {
+		if len(snapshots) >= count {
+			break
+		}
+		snapshot, ok, err := loadSnapshot(filepath.Join(store.Dir, name),
+			component)
+		if err != nil {
+			log.Println("ERROR: history: skipping", err)
+			continue
+		}
+		// different components with the same file name, e.g. "A B" and "A_B"
+		if !ok || !snapshot.Time.Before(before) {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	// order old to new
+	for i, j := 0, len(snapshots)-1; i < j; i, j = i+1, j-1 {
+		snapshots[i], snapshots[j] = snapshots[j], snapshots[i]
+	}
+
+	return snapshots, nil
+}
+
+// loadSnapshot reads a snapshot file of the given component. If the
+// snapshot belongs to another component, only the header is read and ok is
+// false.
+func loadSnapshot(path string, component string) (Snapshot, bool, error) {
+	var snapshot Snapshot
+
+	f, err := os.Open(path)
+	if err != nil {
+		return snapshot, false, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(bufio.NewReader(f))
+
+	var header snapshotHeader
+	err = dec.Decode(&header)
+	if err != nil {
+		return snapshot, false, fmt.Errorf("snapshot %s: %v", path, err)
+	}
+	if header.Component != component {
+		return snapshot, false, nil
+	}
+	snapshot.Time = header.Time
+	snapshot.Component = header.Component
+	snapshot.Report = header.Report
+	snapshot.Issues = make([]*Issue, 0)
+
+	for dec.More() {
+		issue := NewIssue()
+		err = dec.Decode(issue)
+		if err != nil {
+			return snapshot, false, fmt.Errorf("snapshot %s: %v", path, err)
+		}
+		snapshot.Issues = append(snapshot.Issues, issue)
+	}
+
+	return snapshot, true, nil
+}
+
+// OpenBugsBySecurityLevel counts the open bugs for each security level.
+// Bugs in a state of config.States.BugFilter are not counted.
+func OpenBugsBySecurityLevel(issues []*Issue, config Config) map[string]int {
+	counts := make(map[string]int)
+
+	bugs := OpenTickets(FilterByType(issues, config.Types.Bug), config)
+	for _, bug := range bugs {
+		if contains(config.States.BugFilter, bug.Status) {
+			continue
+		}
+		counts[bug.SecurityLevel]++
+	}
+
+	return counts
+}
+
+// HistoryReport generates the history report data for the given
+// snapshots, ordered from old to new.
+func HistoryReport(snapshots []Snapshot, config Config) ReportHistory {
+	history := NewReportHistory()
+
+	levelCounts := make([]map[string]int, 0)
+	levels := make([]string, 0)
+	known := make(map[string]bool)
+
+	for _, snapshot := range snapshots {
+		history.Dates = append(history.Dates,
+			snapshot.Time.Format(config.Formats.Date))
+		history.Times = append(history.Times, snapshot.Time)
+
+		counts := OpenBugsBySecurityLevel(snapshot.Issues, config)
+		for level := range counts {
+			if !known[level] {
+				known[level] = true
+				levels = append(levels, level)
+			}
+		}
+		levelCounts = append(levelCounts, counts)
+
+		count := ReportHistoryCount{
+			Date:  snapshot.Time.Format(config.Formats.Date),
+			Time:  snapshot.Time,
+			Count: snapshot.Report.Bugs.Count,
+		}
+		if len(snapshot.Report.Bugs.Ranges) > 0 {
+			count.Range = snapshot.Report.Bugs.Ranges[0]
+			count.Week = count.Range.ReportCount
+		}
+		history.Bugs = append(history.Bugs, count)
+	}
+
+	sort.Strings(levels)
+	for _, level := range levels {
+		values := make([]string, 0)
+		values = append(values, level)
+		row := BugCountRow{
+			Security: level,
+			Counts:   make([]int, 0),
+		}
+		for _, counts := range levelCounts {
+			values = append(values, fmt.Sprintf("%d", counts[level]))
+			row.Counts = append(row.Counts, counts[level])
+		}
+		history.OpenBugs = append(history.OpenBugs, values)
+		history.BugLevels = append(history.BugLevels, row)
+	}
+
+	return history
+}
@@ -0,0 +1,195 @@
+package ticketstats
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewSnapshotStore(dir)
+
+	snapshots, err := store.Load("Module A", testNow, 12)
+	if err != nil || len(snapshots) != 0 {
+		log.Println("TEST: empty store", err)
+		t.Fail()
+	}
+
+	start := time.Date(2021, 11, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		issue := NewIssue()
+		issue.Key = "A"
+		issue.Childs = append(issue.Childs, issue)
+
+		report := NewReport()
+		report.Component = "Module A"
+		report.Bugs.Count = i
+
+		err = store.Save(Snapshot{
+			Time:      start.AddDate(0, 0, 7*i),
+			Component: "Module A",
+			Report:    report,
+			Issues:    []*Issue{issue},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = store.Save(Snapshot{
+		Time:      start,
+		Component: "Module B",
+		Report:    NewReport(),
+		Issues:    []*Issue{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// same file name as Module A
+	err = store.Save(Snapshot{
+		Time:      start.AddDate(0, 0, 15),
+		Component: "Module_A",
+		Report:    NewReport(),
+		Issues:    []*Issue{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// corrupt and foreign files are skipped
+	corrupt := snapshotFileName(Snapshot{
+		Time:      start.AddDate(0, 0, 16),
+		Component: "Module A",
+	})
+	for name, data := range map[string]string{
+		corrupt:       "{\"Time\": ",
+		"notes.jsonl": "notes",
+	} {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshots, err = store.Load("Module A", testNow, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 {
+		log.Println("TEST: wrong snapshot count", len(snapshots))
+		t.FailNow()
+	}
+	if !snapshots[0].Time.Equal(start.AddDate(0, 0, 7)) ||
+		snapshots[1].Report.Bugs.Count != 2 {
+		log.Println("TEST: wrong snapshot order")
+		t.Fail()
+	}
+	if len(snapshots[1].Issues) != 1 || snapshots[1].Issues[0].Key != "A" {
+		log.Println("TEST: wrong snapshot issues")
+		t.Fail()
+	}
+}
+
+func TestHistoryAsOf(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// snapshots of earlier runs before and after the reference time
+	store := NewSnapshotStore(dir)
+	for _, date := range []time.Time{testNow.AddDate(0, 0, -7),
+		testNow.Add(time.Minute), testNow.AddDate(0, 0, 7)} {
+		err = store.Save(Snapshot{
+			Time:   date,
+			Report: NewReport(),
+			Issues: []*Issue{},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := DefaultConfig()
+	config.History = dir
+	result, err := Run(context.Background(), Options{
+		Source: testSource{NewIssue()},
+		Config: &config,
+		AsOf:   testNow,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dates := result.Reports[0].History.Dates
+	if len(dates) != 2 || dates[0] != "2021-11-08" || dates[1] != "2021-11-15" {
+		log.Println("TEST: wrong history dates", dates)
+		t.Fail()
+	}
+}
+
+func TestHistoryReport(t *testing.T) {
+	config := DefaultConfig()
+
+	bug := func(security string, status string) *Issue {
+		issue := NewIssue()
+		issue.Type = config.Types.Bug
+		issue.SecurityLevel = security
+		issue.Status = status
+		return issue
+	}
+
+	snapshots := []Snapshot{
+		{
+			Time: time.Date(2021, 11, 1, 8, 0, 0, 0, time.UTC),
+			Issues: []*Issue{
+				bug("Internal", "Analysis"),
+				bug("Internal", "Integration"),
+				bug("External", "Analysis"),
+			},
+		},
+		{
+			Time: time.Date(2021, 11, 8, 8, 0, 0, 0, time.UTC),
+			Issues: []*Issue{
+				bug("Internal", "Analysis"),
+				bug("Internal", "Analysis"),
+				bug("External", config.States.Closed),
+			},
+		},
+	}
+
+	history := HistoryReport(snapshots, config)
+
+	if len(history.Dates) != 2 || history.Dates[1] != "2021-11-08" {
+		log.Println("TEST: wrong dates", history.Dates)
+		t.Fail()
+	}
+	if len(history.OpenBugs) != 2 {
+		log.Println("TEST: wrong security levels", history.OpenBugs)
+		t.FailNow()
+	}
+	external := history.OpenBugs[0]
+	internal := history.OpenBugs[1]
+	if external[0] != "External" || external[1] != "1" || external[2] != "0" {
+		log.Println("TEST: wrong external counts", external)
+		t.Fail()
+	}
+	if internal[0] != "Internal" || internal[1] != "1" || internal[2] != "2" {
+		log.Println("TEST: wrong internal counts", internal)
+		t.Fail()
+	}
+	if len(history.Bugs) != 2 {
+		log.Println("TEST: wrong bug history")
+		t.Fail()
+	}
+}
@@ -2,6 +2,7 @@ package ticketstats
 
 import (
 	"fmt"
+	"sort"
 	"time"
 )
 
@@ -17,21 +18,29 @@ type WorkLog struct {
 	Date time.Time
 	// activity (custom value)
 	Activity string
+	// user who booked the time
+	Author string
+	// display name of the author, if provided by the export
+	AuthorName string
+	// work log comment
+	Comment string
 }
 
-// formatWork converts worked hours to a string.
-// The representation splits the hours to weeks, days and hours
-// for better readability.
-func formatWork(w Work) string {
-	if w < 8 {
+// formatWorkDays converts worked hours to a string, splitting the hours to
+// weeks of daysPerWeek days and days of hoursPerDay hours.
+func formatWorkDays(w Work, hoursPerDay Work, daysPerWeek int) string {
+	if hoursPerDay <= 0 || daysPerWeek <= 0 {
+		return fmt.Sprintf("%.2fh", w)
+	}
+	if w < hoursPerDay {
 		// short cut for less than one day
 		return fmt.Sprintf("%.2fh", w)
 	}
 
-	days := int(w / 8)
-	hours := float64(w) - float64(days*8)
-	weeks := days / 5
-	days = days % 5
+	days := int(w / hoursPerDay)
+	hours := float64(w) - float64(days)*float64(hoursPerDay)
+	weeks := days / daysPerWeek
+	days = days % daysPerWeek
 
 	str := ""
 
@@ -48,32 +57,38 @@ func formatWork(w Work) string {
 	return str
 }
 
-// WorkLog.ToString converts a WorkLog to a string for printing.
-func (workLog WorkLog) ToString(config Config) string {
-	return fmt.Sprintf("%s: %s - %s\n",
+// WorkLog.ToString converts a WorkLog to a string for printing. The hours
+// are formatted using the working calendar.
+func (workLog WorkLog) ToString(config Config, calendar *Calendar) string {
+	return fmt.Sprintf("%s: %s - %s (%s)\n",
 		workLog.Activity,
 		workLog.Date.Format(config.Formats.Date),
-		formatWork(workLog.Hours))
+		calendar.FormatWork(workLog.Hours),
+		workLog.Author)
 }
 
 // Issue groups all needed Jira issue data.
 type Issue struct {
-	Summary              string
-	Key                  string
-	Id                   string
-	Parent               string
-	Type                 string
-	Status               string
-	Priority             string
-	Assignee             string
-	Creator              string
-	Created              time.Time
-	Updated              time.Time
-	LastViewed           time.Time
-	AffectsVersions      []string
-	FixVersions          []string
-	Components           []string
-	LogWorks             []WorkLog
+	Summary         string
+	Key             string
+	Id              string
+	Parent          string
+	Type            string
+	Status          string
+	Priority        string
+	Assignee        string
+	Creator         string
+	Created         time.Time
+	Updated         time.Time
+	LastViewed      time.Time
+	AffectsVersions []string
+	FixVersions     []string
+	Components      []string
+	LogWorks        []WorkLog
+	// work logs of the export which couldn't be parsed
+	RejectedWorkLogs []*WorkLogError
+	// dates of the export which couldn't be parsed
+	DateErrors           []*DateError
 	OriginalEstimate     Work
 	RemainingEstimate    Work
 	TimeSpend            Work
@@ -97,13 +112,19 @@ type Issue struct {
 	LinkTriggers         []string
 	LinkLinkIssues       []string
 	LinkParents          []string
-	CustomExternalId     string
-	CustomSupplierRef    string
-	CustomVariant        string
-	CustomActivity       string
-	CustomCategory       string
-	Childs               []*Issue
-	Parents              []*Issue
+	// all links, outward and inward, see ClusterIssues
+	Links []IssueLink
+	// status transitions sorted by date, see AddChangelog
+	History           []StatusChange
+	CustomExternalId  string
+	CustomSupplierRef string
+	CustomVariant     string
+	CustomActivity    string
+	CustomCategory    string
+	// generic custom fields by name, see config.Customs.Fields
+	Fields  map[string]FieldValue
+	Childs  []*Issue `json:"-"`
+	Parents []*Issue `json:"-"`
 }
 
 // NewIssue creates a new issue.
@@ -115,7 +136,10 @@ func NewIssue() *Issue {
 	issue.FixVersions = make([]string, 0)
 	issue.Components = make([]string, 0)
 	issue.LogWorks = make([]WorkLog, 0)
+	issue.RejectedWorkLogs = make([]*WorkLogError, 0)
+	issue.DateErrors = make([]*DateError, 0)
 	issue.Labels = make([]string, 0)
+	issue.Fields = make(map[string]FieldValue)
 	issue.LinkBlocks = make([]string, 0)
 	issue.LinkCauses = make([]string, 0)
 	issue.LinkCloners = make([]string, 0)
@@ -128,6 +152,8 @@ func NewIssue() *Issue {
 	issue.LinkTriggers = make([]string, 0)
 	issue.LinkLinkIssues = make([]string, 0)
 	issue.LinkParents = make([]string, 0)
+	issue.Links = make([]IssueLink, 0)
+	issue.History = make([]StatusChange, 0)
 	issue.Childs = make([]*Issue, 0)
 	issue.Parents = make([]*Issue, 0)
 
@@ -140,7 +166,8 @@ func (issue *Issue) IsResolved() bool {
 }
 
 // Issue.ToString creates a string representation of the issue for console.
-func (issue *Issue) ToString(config Config) string {
+// Work values are formatted using the working calendar.
+func (issue *Issue) ToString(config Config, calendar *Calendar) string {
 	str := ""
 
 	var noDate time.Time
@@ -172,31 +199,44 @@ func (issue *Issue) ToString(config Config) string {
 	if len(issue.LogWorks) > 0 {
 		str += "Work Logs:\n"
 		for _, l := range issue.LogWorks {
-			str += "- " + l.ToString(config)
+			str += "- " + l.ToString(config, calendar)
+		}
+	}
+	if len(issue.DateErrors) > 0 {
+		str += "Invalid dates:\n"
+		for _, err := range issue.DateErrors {
+			str += fmt.Sprintf("- %s: %q\n", err.Column, err.Value)
+		}
+	}
+	if len(issue.RejectedWorkLogs) > 0 {
+		str += "Rejected work logs:\n"
+		for _, err := range issue.RejectedWorkLogs {
+			str += fmt.Sprintf("- %s: %q\n", err.Reason, err.Value)
 		}
 	}
 	if issue.OriginalEstimate > 0 {
 		str += fmt.Sprintf("Original estimate: %s\n",
-			formatWork(issue.OriginalEstimate))
+			calendar.FormatWork(issue.OriginalEstimate))
 	}
 	if issue.RemainingEstimate > 0 {
 		str += fmt.Sprintf("Remaining estimate: %s\n",
-			formatWork(issue.RemainingEstimate))
+			calendar.FormatWork(issue.RemainingEstimate))
 	}
 	if issue.TimeSpend > 0 {
-		str += fmt.Sprintf("Time spend: %s\n", formatWork(issue.TimeSpend))
+		str += fmt.Sprintf("Time spend: %s\n",
+			calendar.FormatWork(issue.TimeSpend))
 	}
 	if issue.SumOriginalEstimate > 0 {
 		str += fmt.Sprintf("Sum original estimate: %s\n",
-			formatWork(issue.SumOriginalEstimate))
+			calendar.FormatWork(issue.SumOriginalEstimate))
 	}
 	if issue.SumRemainingEstimate > 0 {
 		str += fmt.Sprintf("Sum remaining estimate: %s\n",
-			formatWork(issue.SumRemainingEstimate))
+			calendar.FormatWork(issue.SumRemainingEstimate))
 	}
 	if issue.SumTimeSpend > 0 {
 		str += fmt.Sprintf("Sum time spend: %s\n",
-			formatWork(issue.SumTimeSpend))
+			calendar.FormatWork(issue.SumTimeSpend))
 	}
 	if len(issue.Labels) > 0 {
 		str += fmt.Sprintf("Labels: %+v\n", issue.Labels)
@@ -245,6 +285,15 @@ func (issue *Issue) ToString(config Config) string {
 	if len(issue.LinkParents) > 0 {
 		str += fmt.Sprintf("Links parent: %+v\n", issue.LinkParents)
 	}
+	blockedBy := issue.LinkedKeys(LinkTypeBlocks, LinkInward)
+	if len(blockedBy) > 0 {
+		str += fmt.Sprintf("Blocked by: %+v\n", blockedBy)
+	}
+	for _, change := range issue.History {
+		str += fmt.Sprintf("Status change: %s %s -> %s (%s)\n",
+			change.Date.Format("2006-01-02 15:04"), change.From, change.To,
+			change.Author)
+	}
 	if issue.CustomExternalId != "" {
 		str += fmt.Sprintf("External ID: %s\n", issue.CustomExternalId)
 	}
@@ -260,9 +309,118 @@ func (issue *Issue) ToString(config Config) string {
 	if issue.CustomCategory != "" {
 		str += fmt.Sprintf("Category: %s\n", issue.CustomCategory)
 	}
+	names := make([]string, 0)
+	for name := range issue.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		str += fmt.Sprintf("%s: %s\n", name,
+			issue.Fields[name].ToString(config))
+	}
 	if len(issue.Childs) > 0 {
 		str += fmt.Sprintf("Childs: %+v\n", issue.Childs)
 	}
 
 	return str
 }
+
+// Issue.addOutwardLink adds an outward link of the given Jira link type.
+// Besides Links, the links of known types are added to the Link* lists.
+func (issue *Issue) addOutwardLink(linkType string, key string) {
+	issue.Links = append(issue.Links, IssueLink{
+		Type:      linkType,
+		Direction: LinkOutward,
+		Key:       key,
+	})
+
+	switch linkType {
+	case "Blocks":
+		issue.LinkBlocks = append(issue.LinkBlocks, key)
+	case "Causes":
+		issue.LinkCauses = append(issue.LinkCauses, key)
+	case "Cloners":
+		issue.LinkCloners = append(issue.LinkCloners, key)
+	case "Dependency":
+		issue.LinkDependencies = append(issue.LinkDependencies, key)
+	case "Duplicate":
+		issue.LinkDuplicates = append(issue.LinkDuplicates, key)
+	case "Issue split":
+		issue.LinkIssueSplits = append(issue.LinkIssueSplits, key)
+	case "Part":
+		issue.LinkParts = append(issue.LinkParts, key)
+	case "Relates":
+		issue.LinkRelates = append(issue.LinkRelates, key)
+	case "Relation":
+		issue.LinkRelations = append(issue.LinkRelations, key)
+	case "Triggers":
+		issue.LinkTriggers = append(issue.LinkTriggers, key)
+	case "linkIssue":
+		issue.LinkLinkIssues = append(issue.LinkLinkIssues, key)
+	case "parent":
+		issue.LinkParents = append(issue.LinkParents, key)
+	}
+}
+
+// Issue.addInwardLink adds an inward link of the given Jira link type.
+func (issue *Issue) addInwardLink(linkType string, key string) {
+	issue.Links = append(issue.Links, IssueLink{
+		Type:      linkType,
+		Direction: LinkInward,
+		Key:       key,
+	})
+}
+
+// Issue.outwardLinks returns the outward links of the Link* lists, e.g. for
+// issues created without addOutwardLink.
+func (issue *Issue) outwardLinks() []IssueLink {
+	links := make([]IssueLink, 0)
+	lists := []struct {
+		linkType string
+		keys     []string
+	}{
+		{"Blocks", issue.LinkBlocks},
+		{"Causes", issue.LinkCauses},
+		{"Cloners", issue.LinkCloners},
+		{"Dependency", issue.LinkDependencies},
+		{"Duplicate", issue.LinkDuplicates},
+		{"Issue split", issue.LinkIssueSplits},
+		{"Part", issue.LinkParts},
+		{"Relates", issue.LinkRelates},
+		{"Relation", issue.LinkRelations},
+		{"Triggers", issue.LinkTriggers},
+		{"linkIssue", issue.LinkLinkIssues},
+		{"parent", issue.LinkParents},
+	}
+	for _, list := range lists {
+		for _, key := range list.keys {
+			links = append(links, IssueLink{
+				Type:      list.linkType,
+				Direction: LinkOutward,
+				Key:       key,
+			})
+		}
+	}
+	return links
+}
+
+// Issue.setCustomField sets the custom field value for the given field
+// (CSV column) name. Fields not configured in config.Customs are ignored.
+func (issue *Issue) setCustomField(name string, val string, config Config) {
+	if fieldType, ok := config.Customs.Fields[name]; ok {
+		issue.setField(name, fieldType, val, config)
+	}
+
+	switch name {
+	case config.Customs.ExternalId:
+		issue.CustomExternalId = val
+	case config.Customs.SupplierReference:
+		issue.CustomSupplierRef = val
+	case config.Customs.Variant:
+		issue.CustomVariant = val
+	case config.Customs.Account:
+		issue.CustomActivity = val
+	case config.Customs.Category:
+		issue.CustomCategory = val
+	}
+}
@@ -3,32 +3,31 @@ package ticketstats
 import (
 	"log"
 	"testing"
-	"time"
 )
 
 func TestFormatWork(t *testing.T) {
 	var work Work = 7.5
-	if formatWork(work) != "7.50h" {
-		log.Println("TEST: 7.5h wrong:", formatWork(work))
+	if testCalendar.FormatWork(work) != "7.50h" {
+		log.Println("TEST: 7.5h wrong:", testCalendar.FormatWork(work))
 		t.Fail()
 	}
 
 	work = 12.5
-	if formatWork(work) != "1d 4.50h" {
-		log.Println("TEST: 12.5 wrong:", formatWork(work))
+	if testCalendar.FormatWork(work) != "1d 4.50h" {
+		log.Println("TEST: 12.5 wrong:", testCalendar.FormatWork(work))
 		t.Fail()
 	}
 
 	work = 60.5
-	if formatWork(work) != "1w 2d 4.50h" {
-		log.Println("TEST: 60.5 wrong:", formatWork(work))
+	if testCalendar.FormatWork(work) != "1w 2d 4.50h" {
+		log.Println("TEST: 60.5 wrong:", testCalendar.FormatWork(work))
 		t.Fail()
 	}
 }
 
 func TestIsResolved(t *testing.T) {
 	issue := NewIssue()
-	issue.Resolved = time.Now()
+	issue.Resolved = testNow
 
 	if !issue.IsResolved() {
 		log.Println("TEST: resolved issue wrong")
@@ -0,0 +1,281 @@
+package ticketstats
+
+import (
+	"sort"
+	"time"
+)
+
+// issueIndex groups issues by a key, e.g. by type.
+type issueIndex struct {
+	// keys in order of their first occurrence
+	keys   []string
+	issues map[string][]*Issue
+}
+
+// newIssueIndex indexes the issues by the keys of each issue. An issue is
+// added once per distinct key, the issues of a key keep the order of the
+// given list.
+func newIssueIndex(issues []*Issue,
+	keys func(issue *Issue) []string) *issueIndex {
+	index := &issueIndex{
+		keys:   make([]string, 0),
+		issues: make(map[string][]*Issue),
+	}
+
+	for _, issue := range issues {
+		for _, key := range keys(issue) {
+			list, ok := index.issues[key]
+			if !ok {
+				index.keys = append(index.keys, key)
+			}
+			// repeated keys of the issue, e.g. several work logs of an author
+			if len(list) > 0 && list[len(list)-1] == issue {
+				continue
+			}
+			index.issues[key] = append(list, issue)
+		}
+	}
+
+	return index
+}
+
+// issueIndex.get returns a copy of the issues of the key, i.e. the result
+// can be sorted by the caller.
+func (index *issueIndex) get(key string) []*Issue {
+	return append(make([]*Issue, 0, len(index.issues[key])),
+		index.issues[key]...)
+}
+
+// IssueSet is a list of issues with indexes for repeated grouping, e.g. by
+// type or label, and date sorted views for window queries. The indexes are
+// built on first use, so grouping all issues by any attribute takes linear
+// time. An IssueSet must not be modified and is not safe for concurrent use.
+type IssueSet struct {
+	issues []*Issue
+
+	byType          *issueIndex
+	byLabel         *issueIndex
+	byComponent     *issueIndex
+	byFixVersion    *issueIndex
+	byStatus        *issueIndex
+	bySecurityLevel *issueIndex
+	byAuthor        *issueIndex
+
+	// issues sorted by created and resolved date, unresolved issues are not
+	// part of byResolved
+	byCreated  []*Issue
+	byResolved []*Issue
+}
+
+// NewIssueSet creates an IssueSet for the issues.
+func NewIssueSet(issues []*Issue) *IssueSet {
+	return &IssueSet{
+		issues: issues,
+	}
+}
+
+// IssueSet.Issues returns all issues of the set.
+func (set *IssueSet) Issues() []*Issue {
+	return set.issues
+}
+
+// IssueSet.Len returns the number of issues of the set.
+func (set *IssueSet) Len() int {
+	return len(set.issues)
+}
+
+// IssueSet.Where returns a new set of the issues matching the predicate.
+func (set *IssueSet) Where(predicate Predicate) *IssueSet {
+	return NewIssueSet(Filter(set.issues, predicate))
+}
+
+// IssueSet.index returns the index, it is built if it doesn't exist yet.
+func (set *IssueSet) index(index **issueIndex,
+	keys func(issue *Issue) []string) *issueIndex {
+	if *index == nil {
+		*index = newIssueIndex(set.issues, keys)
+	}
+	return *index
+}
+
+// IssueSet.typeIndex returns the index by ticket type.
+func (set *IssueSet) typeIndex() *issueIndex {
+	return set.index(&set.byType, func(issue *Issue) []string {
+		return []string{issue.Type}
+	})
+}
+
+// IssueSet.labelIndex returns the index by label.
+func (set *IssueSet) labelIndex() *issueIndex {
+	return set.index(&set.byLabel, func(issue *Issue) []string {
+		return issue.Labels
+	})
+}
+
+// IssueSet.componentIndex returns the index by component.
+func (set *IssueSet) componentIndex() *issueIndex {
+	return set.index(&set.byComponent, func(issue *Issue) []string {
+		return issue.Components
+	})
+}
+
+// IssueSet.fixVersionIndex returns the index by fix version.
+func (set *IssueSet) fixVersionIndex() *issueIndex {
+	return set.index(&set.byFixVersion, func(issue *Issue) []string {
+		return issue.FixVersions
+	})
+}
+
+// IssueSet.statusIndex returns the index by status.
+func (set *IssueSet) statusIndex() *issueIndex {
+	return set.index(&set.byStatus, func(issue *Issue) []string {
+		return []string{issue.Status}
+	})
+}
+
+// IssueSet.securityLevelIndex returns the index by security level.
+func (set *IssueSet) securityLevelIndex() *issueIndex {
+	return set.index(&set.bySecurityLevel, func(issue *Issue) []string {
+		return []string{issue.SecurityLevel}
+	})
+}
+
+// IssueSet.authorIndex returns the index by work log author.
+func (set *IssueSet) authorIndex() *issueIndex {
+	return set.index(&set.byAuthor, func(issue *Issue) []string {
+		authors := make([]string, 0)
+		for _, log := range issue.LogWorks {
+			if log.Author != "" {
+				authors = append(authors, log.Author)
+			}
+		}
+		return authors
+	})
+}
+
+// IssueSet.ByType returns the issues of the given type, see FilterByType.
+func (set *IssueSet) ByType(ticketType string) []*Issue {
+	return set.typeIndex().get(ticketType)
+}
+
+// IssueSet.ByLabel returns the issues with the given label, see
+// FilterByLabel.
+func (set *IssueSet) ByLabel(label string) []*Issue {
+	return set.labelIndex().get(label)
+}
+
+// IssueSet.ByComponent returns the issues of the given component, see
+// FilterByComponent.
+func (set *IssueSet) ByComponent(component string) []*Issue {
+	return set.componentIndex().get(component)
+}
+
+// IssueSet.ByFixVersion returns the issues with the given fix version, see
+// FilterByFixVersion.
+func (set *IssueSet) ByFixVersion(fixVersion string) []*Issue {
+	return set.fixVersionIndex().get(fixVersion)
+}
+
+// IssueSet.ByStatus returns the issues in the given state.
+func (set *IssueSet) ByStatus(status string) []*Issue {
+	return set.statusIndex().get(status)
+}
+
+// IssueSet.BySecurityLevel returns the issues with the given security
+// level, see FilterBySecurityLevel.
+func (set *IssueSet) BySecurityLevel(securityLevel string) []*Issue {
+	return set.securityLevelIndex().get(securityLevel)
+}
+
+// IssueSet.ByAuthor returns the issues with work logs of the given author.
+func (set *IssueSet) ByAuthor(author string) []*Issue {
+	return set.authorIndex().get(author)
+}
+
+// IssueSet.Types returns all ticket types of the set, see Types.
+func (set *IssueSet) Types() []string {
+	return append([]string{}, set.typeIndex().keys...)
+}
+
+// IssueSet.Labels returns all labels of the set, see Labels.
+func (set *IssueSet) Labels() []string {
+	return append([]string{}, set.labelIndex().keys...)
+}
+
+// IssueSet.Components returns all components of the set, see Components.
+func (set *IssueSet) Components() []string {
+	return append([]string{}, set.componentIndex().keys...)
+}
+
+// IssueSet.FixVersions returns all fix versions of the set, see
+// FixVersions.
+func (set *IssueSet) FixVersions() []string {
+	return append([]string{}, set.fixVersionIndex().keys...)
+}
+
+// IssueSet.Statuses returns all states of the set.
+func (set *IssueSet) Statuses() []string {
+	return append([]string{}, set.statusIndex().keys...)
+}
+
+// IssueSet.SecurityLevels returns all security levels of the set, see
+// SecurityLevels.
+func (set *IssueSet) SecurityLevels() []string {
+	return append([]string{}, set.securityLevelIndex().keys...)
+}
+
+// IssueSet.Authors returns all work log authors of the set, see Authors.
+func (set *IssueSet) Authors() []string {
+	return append([]string{}, set.authorIndex().keys...)
+}
+
+// sortedByDate returns the issues with a date sorted by the date.
+func sortedByDate(issues []*Issue,
+	date func(issue *Issue) time.Time) []*Issue {
+	sorted := make([]*Issue, 0)
+	for _, issue := range issues {
+		if !date(issue).IsZero() {
+			sorted = append(sorted, issue)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return date(sorted[i]).Before(date(sorted[j]))
+	})
+	return sorted
+}
+
+// inWindow returns the issues of the date sorted list within the window,
+// using binary search.
+func inWindow(sorted []*Issue, window Window,
+	date func(issue *Issue) time.Time) []*Issue {
+	first := sort.Search(len(sorted), func(i int) bool {
+		return date(sorted[i]).After(window.Start)
+	})
+	last := sort.Search(len(sorted), func(i int) bool {
+		return date(sorted[i]).After(window.End)
+	})
+	if last < first {
+		return make([]*Issue, 0)
+	}
+	return append(make([]*Issue, 0, last-first), sorted[first:last]...)
+}
+
+// IssueSet.CreatedIn returns the issues created within the window ordered
+// by created date, see CreatedIn.
+func (set *IssueSet) CreatedIn(window Window) []*Issue {
+	created := func(issue *Issue) time.Time { return issue.Created }
+	if set.byCreated == nil {
+		set.byCreated = sortedByDate(set.issues, created)
+	}
+	return inWindow(set.byCreated, window, created)
+}
+
+// IssueSet.ClosedIn returns the issues resolved within the window ordered
+// by resolution date, see ClosedIn.
+func (set *IssueSet) ClosedIn(window Window) []*Issue {
+	resolved := func(issue *Issue) time.Time { return issue.Resolved }
+	if set.byResolved == nil {
+		set.byResolved = sortedByDate(set.issues, resolved)
+	}
+	return inWindow(set.byResolved, window, resolved)
+}
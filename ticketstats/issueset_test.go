@@ -0,0 +1,249 @@
+package ticketstats
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// benchmarkIssues creates n random issues with roughly n/10 labels, n/50 fix
+// versions and n/40 work log authors.
+func benchmarkIssues(n int) []*Issue {
+	random := rand.New(rand.NewSource(1))
+	types := []string{"Bug", "New Feature", "Improvement", "Task", "Story"}
+	statuses := []string{"Open", "In Progress", "Review", "Verification",
+		"Closed"}
+
+	issues := make([]*Issue, 0, n)
+	for i := 0; i < n; i++ {
+		issue := NewIssue()
+		issue.Key = fmt.Sprintf("PRJ-%d", i)
+		issue.Id = fmt.Sprint(i)
+		issue.Type = types[random.Intn(len(types))]
+		issue.Status = statuses[random.Intn(len(statuses))]
+		issue.Priority = fmt.Sprintf("P%d", random.Intn(4))
+		issue.SecurityLevel = fmt.Sprintf("S%d", random.Intn(5))
+		for j := 0; j < 2; j++ {
+			issue.Labels = append(issue.Labels,
+				fmt.Sprintf("label%d", random.Intn(n/10+1)))
+		}
+		issue.Components = append(issue.Components,
+			fmt.Sprintf("component%d", random.Intn(20)))
+		issue.FixVersions = append(issue.FixVersions,
+			fmt.Sprintf("v%d", random.Intn(n/50+1)))
+		issue.Created = testNow.AddDate(0, 0, -random.Intn(700))
+		issue.Updated = issue.Created.AddDate(0, 0, random.Intn(30))
+		if issue.Status == "Closed" {
+			issue.Resolved = issue.Updated
+		}
+		for j := 0; j < 2; j++ {
+			issue.LogWorks = append(issue.LogWorks, WorkLog{
+				Hours:    Work(1 + random.Intn(8)),
+				Date:     testNow.AddDate(0, 0, -random.Intn(400)),
+				Author:   fmt.Sprintf("author%d", random.Intn(n/40+1)),
+				Activity: "dev",
+			})
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// addBenchmarkHistory adds status changes to every third issue. The first
+// change may be before the creation, several changes may be on one day.
+func addBenchmarkHistory(issues []*Issue) {
+	for i, issue := range issues {
+		if i%3 != 0 {
+			continue
+		}
+		date := issue.Created.AddDate(0, 0, i%5-1)
+		for _, status := range []string{"In Progress", "Review", "Open",
+			"Closed"} {
+			from := "Open"
+			if len(issue.History) > 0 {
+				from = issue.History[len(issue.History)-1].To
+			}
+			issue.addStatusChange(StatusChange{
+				Date: date,
+				From: from,
+				To:   status,
+			})
+			date = date.AddDate(0, 0, i%7)
+		}
+	}
+}
+
+func TestIssueSet(t *testing.T) {
+	issues := benchmarkIssues(500)
+	set := NewIssueSet(issues)
+
+	if set.Len() != 500 || len(set.Issues()) != 500 {
+		log.Println("TEST: wrong set size", set.Len())
+		t.Fail()
+	}
+
+	same := func(name string, a []*Issue, b []*Issue) {
+		if predicateKeys(a) != predicateKeys(b) {
+			log.Println("TEST: wrong index result", name)
+			t.Fail()
+		}
+	}
+	sameKeys := func(name string, a []string, b []string) {
+		if fmt.Sprint(a) != fmt.Sprint(b) {
+			log.Println("TEST: wrong index keys", name, a, b)
+			t.Fail()
+		}
+	}
+
+	sameKeys("types", set.Types(), Types(issues))
+	for _, issueType := range Types(issues) {
+		same(issueType, set.ByType(issueType), FilterByType(issues, issueType))
+	}
+	sameKeys("labels", set.Labels(), Labels(issues))
+	for _, label := range Labels(issues) {
+		same(label, set.ByLabel(label), FilterByLabel(issues, label))
+	}
+	sameKeys("components", set.Components(), Components(issues))
+	for _, component := range Components(issues) {
+		same(component, set.ByComponent(component),
+			FilterByComponent(issues, component))
+	}
+	sameKeys("fix versions", set.FixVersions(), FixVersions(issues))
+	for _, version := range FixVersions(issues) {
+		same(version, set.ByFixVersion(version),
+			FilterByFixVersion(issues, version))
+	}
+	sameKeys("security levels", set.SecurityLevels(), SecurityLevels(issues))
+	for _, level := range SecurityLevels(issues) {
+		same(level, set.BySecurityLevel(level),
+			FilterBySecurityLevel(issues, level))
+	}
+	for _, status := range set.Statuses() {
+		same(status, set.ByStatus(status), Filter(issues, ByStatus(status)))
+	}
+	sameKeys("authors", set.Authors(), Authors(issues))
+
+	// the results are copies, sorting them keeps the index intact
+	bugs := set.ByType("Bug")
+	OrderByPriority(bugs)
+	same("sorted copy", set.ByType("Bug"), FilterByType(issues, "Bug"))
+}
+
+func TestIssueSetWindows(t *testing.T) {
+	issues := benchmarkIssues(500)
+	set := NewIssueSet(issues)
+
+	for _, window := range DefaultConfig().TimeWindows(testNow) {
+		created := set.CreatedIn(window)
+		if len(created) != len(CreatedIn(issues, window)) {
+			log.Println("TEST: wrong created issues", window.Name)
+			t.Fail()
+		}
+		for i := 1; i < len(created); i++ {
+			if created[i].Created.Before(created[i-1].Created) {
+				log.Println("TEST: created issues not sorted")
+				t.Fail()
+				break
+			}
+		}
+		if len(set.ClosedIn(window)) != len(ClosedIn(issues, window)) {
+			log.Println("TEST: wrong closed issues", window.Name)
+			t.Fail()
+		}
+	}
+
+	empty := Window{Start: testNow, End: testNow.AddDate(0, 0, -1)}
+	if len(set.CreatedIn(empty)) != 0 {
+		log.Println("TEST: issues in empty window")
+		t.Fail()
+	}
+}
+
+// benchmarkSizes are the issue counts of the benchmarks. The time per
+// operation should grow linearly with the size.
+var benchmarkSizes = []int{1000, 4000, 16000}
+
+func BenchmarkGenerateReport(b *testing.B) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, n := range benchmarkSizes {
+		issues := benchmarkIssues(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := GenerateReport(issues, "", DefaultConfig(),
+					Options{AsOf: testNow})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGenerateReportHistory(b *testing.B) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	for _, n := range benchmarkSizes {
+		issues := benchmarkIssues(n)
+		addBenchmarkHistory(issues)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, err := GenerateReport(issues, "", DefaultConfig(),
+					Options{AsOf: testNow})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGroupByLabel(b *testing.B) {
+	for _, n := range benchmarkSizes {
+		issues := benchmarkIssues(n)
+		b.Run(fmt.Sprintf("Filter/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, label := range Labels(issues) {
+					FilterByLabel(issues, label)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("IssueSet/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				set := NewIssueSet(issues)
+				for _, label := range set.Labels() {
+					set.ByLabel(label)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWindows(b *testing.B) {
+	windows := DefaultConfig().TimeWindows(testNow)
+	for _, n := range benchmarkSizes {
+		issues := benchmarkIssues(n)
+		b.Run(fmt.Sprintf("Filter/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, window := range windows {
+					CreatedIn(issues, window)
+					ClosedIn(issues, window)
+				}
+			}
+		})
+		set := NewIssueSet(issues)
+		b.Run(fmt.Sprintf("IssueSet/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, window := range windows {
+					set.CreatedIn(window)
+					set.ClosedIn(window)
+				}
+			}
+		})
+	}
+}
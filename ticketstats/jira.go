@@ -0,0 +1,498 @@
+package ticketstats
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Date formats used by the Jira REST API.
+const (
+	jiraApiDateTime = "2006-01-02T15:04:05.000-0700"
+	jiraApiDate     = "2006-01-02"
+)
+
+// JiraSource reads the issues matching a JQL query from the Jira REST API
+// (/rest/api/2/search).
+type JiraSource struct {
+	// Jira server URL, e.g. "https://jira.example.com"
+	BaseUrl string
+	// JQL query selecting the issues
+	Jql string
+	// user name for basic auth, if empty the token is used as bearer token
+	User string
+	// password or API token
+	Token string
+	// issues requested per page
+	PageSize int
+	// HTTP client used for the requests
+	Client *http.Client
+	Config Config
+}
+
+// NewJiraSource creates a new JiraSource for the given server and query.
+func NewJiraSource(baseUrl string, jql string, config Config) *JiraSource {
+	return &JiraSource{
+		BaseUrl:  strings.TrimSuffix(baseUrl, "/"),
+		Jql:      jql,
+		PageSize: 100,
+		Client:   http.DefaultClient,
+		Config:   config,
+	}
+}
+
+// jiraSearchResult is one page of a /rest/api/2/search response.
+type jiraSearchResult struct {
+	StartAt    int               `json:"startAt"`
+	MaxResults int               `json:"maxResults"`
+	Total      int               `json:"total"`
+	Issues     []jiraIssue       `json:"issues"`
+	Names      map[string]string `json:"names"`
+}
+
+// jiraIssue is an issue of the search response.
+// The fields are kept raw to access the custom fields by id.
+type jiraIssue struct {
+	Id     string          `json:"id"`
+	Key    string          `json:"key"`
+	Fields json.RawMessage `json:"fields"`
+	// only set with expand=changelog
+	Changelog jiraChangelog `json:"changelog"`
+}
+
+// jiraFields groups the Jira system fields of an issue.
+type jiraFields struct {
+	Summary                       string          `json:"summary"`
+	IssueType                     jiraNamed       `json:"issuetype"`
+	Status                        jiraNamed       `json:"status"`
+	Priority                      jiraNamed       `json:"priority"`
+	Assignee                      jiraUser        `json:"assignee"`
+	Creator                       jiraUser        `json:"creator"`
+	Created                       string          `json:"created"`
+	Updated                       string          `json:"updated"`
+	LastViewed                    string          `json:"lastViewed"`
+	Versions                      []jiraNamed     `json:"versions"`
+	FixVersions                   []jiraNamed     `json:"fixVersions"`
+	Components                    []jiraNamed     `json:"components"`
+	Worklog                       jiraWorklogs    `json:"worklog"`
+	TimeOriginalEstimate          int             `json:"timeoriginalestimate"`
+	TimeEstimate                  int             `json:"timeestimate"`
+	TimeSpent                     int             `json:"timespent"`
+	AggregateTimeOriginalEstimate int             `json:"aggregatetimeoriginalestimate"`
+	AggregateTimeEstimate         int             `json:"aggregatetimeestimate"`
+	AggregateTimeSpent            int             `json:"aggregatetimespent"`
+	Security                      jiraNamed       `json:"security"`
+	Labels                        []string        `json:"labels"`
+	Resolution                    jiraNamed       `json:"resolution"`
+	ResolutionDate                string          `json:"resolutiondate"`
+	DueDate                       string          `json:"duedate"`
+	IssueLinks                    []jiraIssueLink `json:"issuelinks"`
+	Parent                        jiraParent      `json:"parent"`
+}
+
+// jiraNamed is a Jira object identified by name, e.g. a status or version.
+type jiraNamed struct {
+	Name string `json:"name"`
+}
+
+// jiraUser is a Jira user reference.
+type jiraUser struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// jiraUser.String returns the user name, or the display name if Jira
+// doesn't provide user names.
+func (user jiraUser) String() string {
+	if user.Name != "" {
+		return user.Name
+	}
+	return user.DisplayName
+}
+
+// jiraWorklogs is the (paged) work log list of an issue.
+type jiraWorklogs struct {
+	StartAt    int           `json:"startAt"`
+	MaxResults int           `json:"maxResults"`
+	Total      int           `json:"total"`
+	Worklogs   []jiraWorklog `json:"worklogs"`
+}
+
+// jiraWorklog is a single work log entry.
+type jiraWorklog struct {
+	Author           jiraUser `json:"author"`
+	Comment          string   `json:"comment"`
+	Started          string   `json:"started"`
+	TimeSpentSeconds int      `json:"timeSpentSeconds"`
+}
+
+// jiraChangelog is the change history of an issue.
+type jiraChangelog struct {
+	Histories []jiraHistory `json:"histories"`
+}
+
+// jiraHistory is a change of an issue, grouping the changed fields.
+type jiraHistory struct {
+	Author  jiraUser          `json:"author"`
+	Created string            `json:"created"`
+	Items   []jiraHistoryItem `json:"items"`
+}
+
+// jiraHistoryItem is the change of a single field.
+type jiraHistoryItem struct {
+	Field      string `json:"field"`
+	FromString string `json:"fromString"`
+	ToString   string `json:"toString"`
+}
+
+// jiraIssueLink is a link between two issues.
+// Only one of OutwardIssue and InwardIssue is set.
+type jiraIssueLink struct {
+	Type         jiraNamed  `json:"type"`
+	OutwardIssue *jiraIssue `json:"outwardIssue"`
+	InwardIssue  *jiraIssue `json:"inwardIssue"`
+}
+
+// jiraParent references the parent of a sub-task.
+type jiraParent struct {
+	Id  string `json:"id"`
+	Key string `json:"key"`
+}
+
+// JiraSource.Issues runs the JQL query and loads all result pages.
+func (source *JiraSource) Issues() ([]*Issue, error) {
+	return source.IssuesContext(context.Background())
+}
+
+// JiraSource.IssuesContext runs the JQL query and loads all result pages.
+// The requests are canceled if the context is done.
+func (source *JiraSource) IssuesContext(ctx context.Context) ([]*Issue,
+	error) {
+	issues := make([]*Issue, 0)
+
+	startAt := 0
+	for {
+		params := url.Values{}
+		params.Set("jql", source.Jql)
+		params.Set("startAt", strconv.Itoa(startAt))
+		params.Set("maxResults", strconv.Itoa(source.PageSize))
+		params.Set("fields", "*all")
+		params.Set("expand", "names,changelog")
+
+		var page jiraSearchResult
+		err := source.get(ctx, "/rest/api/2/search", params, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ji := range page.Issues {
+			issue, err := convertJiraIssue(ji, page.Names, source.Config)
+			if err != nil {
+				return nil, err
+			}
+			err = source.completeWorkLogs(ctx, ji, issue)
+			if err != nil {
+				return nil, err
+			}
+			issues = append(issues, issue)
+		}
+
+		startAt += len(page.Issues)
+		if len(page.Issues) == 0 || startAt >= page.Total {
+			break
+		}
+	}
+
+	log.Println("INFO:", len(issues), "issues loaded from Jira.")
+
+	return issues, nil
+}
+
+// completeWorkLogs loads all work logs of the issue if the search result
+// contains only the first ones.
+func (source *JiraSource) completeWorkLogs(ctx context.Context, ji jiraIssue,
+	issue *Issue) error {
+	var fields jiraFields
+	err := json.Unmarshal(ji.Fields, &fields)
+	if err != nil {
+		return err
+	}
+	if fields.Worklog.Total <= len(fields.Worklog.Worklogs) {
+		return nil
+	}
+
+	var worklogs jiraWorklogs
+	err = source.get(ctx, "/rest/api/2/issue/"+ji.Key+"/worklog", nil,
+		&worklogs)
+	if err != nil {
+		return err
+	}
+
+	issue.LogWorks = make([]WorkLog, 0)
+	for _, wl := range worklogs.Worklogs {
+		issue.LogWorks = append(issue.LogWorks, wl.toWorkLog(issue))
+	}
+
+	return nil
+}
+
+// get requests the given API path and decodes the JSON response to result.
+func (source *JiraSource) get(ctx context.Context, path string,
+	params url.Values, result interface{}) error {
+	u := source.BaseUrl + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if source.User != "" {
+		req.SetBasicAuth(source.User, source.Token)
+	} else if source.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+source.Token)
+	}
+
+	client := source.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jira: %s %s: %s", resp.Status, path,
+			strings.TrimSpace(string(body)))
+	}
+
+	return json.Unmarshal(body, result)
+}
+
+// convertJiraIssue maps a Jira REST issue to an Issue.
+// names maps the custom field ids to the field names.
+func convertJiraIssue(ji jiraIssue, names map[string]string,
+	config Config) (*Issue, error) {
+	var fields jiraFields
+	err := json.Unmarshal(ji.Fields, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("jira: issue %s: %v", ji.Key, err)
+	}
+
+	issue := NewIssue()
+	issue.Summary = fields.Summary
+	issue.Key = ji.Key
+	issue.Id = ji.Id
+	issue.Parent = fields.Parent.Id
+	issue.Type = fields.IssueType.Name
+	issue.Status = fields.Status.Name
+	issue.Priority = fields.Priority.Name
+	issue.Assignee = fields.Assignee.String()
+	issue.Creator = fields.Creator.String()
+	issue.Created = issue.parseApiDate("Created", fields.Created)
+	issue.Updated = issue.parseApiDate("Updated", fields.Updated)
+	issue.LastViewed = issue.parseApiDate("Last Viewed",
+		fields.LastViewed)
+	for _, v := range fields.Versions {
+		issue.AffectsVersions = append(issue.AffectsVersions, v.Name)
+	}
+	for _, v := range fields.FixVersions {
+		issue.FixVersions = append(issue.FixVersions, v.Name)
+	}
+	for _, c := range fields.Components {
+		issue.Components = append(issue.Components, c.Name)
+	}
+	for _, history := range ji.Changelog.Histories {
+		for _, item := range history.Items {
+			if item.Field != "status" {
+				continue
+			}
+			issue.addStatusChange(StatusChange{
+				Date:   issue.parseApiDate("Status", history.Created),
+				From:   item.FromString,
+				To:     item.ToString,
+				Author: history.Author.String(),
+			})
+		}
+	}
+	for _, wl := range fields.Worklog.Worklogs {
+		issue.LogWorks = append(issue.LogWorks, wl.toWorkLog(issue))
+	}
+	issue.OriginalEstimate = secondsToWork(fields.TimeOriginalEstimate)
+	issue.RemainingEstimate = secondsToWork(fields.TimeEstimate)
+	issue.TimeSpend = secondsToWork(fields.TimeSpent)
+	issue.SumOriginalEstimate = secondsToWork(
+		fields.AggregateTimeOriginalEstimate)
+	issue.SumRemainingEstimate = secondsToWork(fields.AggregateTimeEstimate)
+	issue.SumTimeSpend = secondsToWork(fields.AggregateTimeSpent)
+	issue.SecurityLevel = fields.Security.Name
+	issue.Labels = append(issue.Labels, fields.Labels...)
+	issue.Resolution = fields.Resolution.Name
+	issue.Resolved = issue.parseApiDate("Resolved", fields.ResolutionDate)
+	issue.Due = issue.parseApiDate("Due Date", fields.DueDate)
+	for _, link := range fields.IssueLinks {
+		if link.OutwardIssue != nil {
+			issue.addOutwardLink(link.Type.Name, link.OutwardIssue.Key)
+		}
+		if link.InwardIssue != nil {
+			issue.addInwardLink(link.Type.Name, link.InwardIssue.Key)
+		}
+	}
+
+	// custom fields are identified by name, using the CSV column names
+	var raw map[string]json.RawMessage
+	err = json.Unmarshal(ji.Fields, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("jira: issue %s: %v", ji.Key, err)
+	}
+	for id, value := range raw {
+		if !strings.HasPrefix(id, "customfield_") {
+			continue
+		}
+		// without the names expansion, the fields are named by id
+		name, ok := names[id]
+		if !ok {
+			name = id
+		}
+		val := convertCustomValue(value)
+		if val == "" {
+			continue
+		}
+		issue.setCustomField("Custom field ("+name+")", val, config)
+	}
+
+	for _, err := range issue.DateErrors {
+		log.Println("ERROR:", err)
+	}
+
+	return issue, nil
+}
+
+// ParseJson parses saved Jira REST search responses (/rest/api/2/search
+// with fields=*all and expand=names). The data is a single response or a
+// list of responses, e.g. of all result pages. Only the work logs contained
+// in the responses are considered.
+func ParseJson(r io.Reader, config Config) ([]*Issue, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]jiraSearchResult, 0)
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &pages)
+	} else {
+		var page jiraSearchResult
+		err = json.Unmarshal(data, &page)
+		pages = append(pages, page)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse JSON: %v", err)
+	}
+
+	issues := make([]*Issue, 0)
+	for _, page := range pages {
+		for _, ji := range page.Issues {
+			issue, err := convertJiraIssue(ji, page.Names, config)
+			if err != nil {
+				return nil, err
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// jiraWorklog.toWorkLog converts a Jira REST work log of the issue to a
+// WorkLog.
+func (wl jiraWorklog) toWorkLog(issue *Issue) WorkLog {
+	return WorkLog{
+		Hours:      secondsToWork(wl.TimeSpentSeconds),
+		Date:       issue.parseApiDate("Log Work", wl.Started),
+		Activity:   workLogActivity(wl.Comment),
+		Author:     wl.Author.String(),
+		AuthorName: wl.Author.DisplayName,
+		Comment:    strings.TrimSpace(wl.Comment),
+	}
+}
+
+// Issue.parseApiDate parses a Jira REST date or date time. Empty values
+// result in the zero time, unparseable dates are added to DateErrors and
+// result in the zero time.
+func (issue *Issue) parseApiDate(column string, data string) time.Time {
+	if data == "" {
+		return time.Time{}
+	}
+	layout := jiraApiDateTime
+	if len(data) == len(jiraApiDate) {
+		layout = jiraApiDate
+	}
+	t, err := time.Parse(layout, data)
+	if err != nil {
+		issue.DateErrors = append(issue.DateErrors, &DateError{
+			Key:    issue.Key,
+			Column: column,
+			Value:  data,
+		})
+		return time.Time{}
+	}
+	return t
+}
+
+// convertCustomValue converts the JSON value of a custom field to a string.
+// Option values ({"value": ...}) and named values ({"name": ...}) are
+// reduced to the value, lists are joined by ", ".
+func convertCustomValue(data json.RawMessage) string {
+	var value interface{}
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return ""
+	}
+	return customValueString(value)
+}
+
+// customValueString converts a decoded JSON value to a string.
+func customValueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case map[string]interface{}:
+		for _, key := range []string{"value", "name", "key"} {
+			if val, ok := v[key]; ok {
+				return customValueString(val)
+			}
+		}
+	case []interface{}:
+		values := make([]string, 0)
+		for _, val := range v {
+			str := customValueString(val)
+			if str != "" {
+				values = append(values, str)
+			}
+		}
+		return strings.Join(values, ", ")
+	}
+	return ""
+}
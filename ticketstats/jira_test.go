@@ -0,0 +1,178 @@
+package ticketstats
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newJiraTestServer creates a Jira stand-in serving the recorded responses
+// from testdata.
+func newJiraTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			if !ok || user != "user" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			var file string
+			switch r.URL.Path {
+			case "/rest/api/2/search":
+				if r.URL.Query().Get("jql") != "project = PRJ" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				switch r.URL.Query().Get("startAt") {
+				case "0":
+					file = "testdata/jira_search_page1.json"
+				case "2":
+					file = "testdata/jira_search_page2.json"
+				}
+			case "/rest/api/2/issue/PRJ-3/worklog":
+				file = "testdata/jira_worklog_PRJ-3.json"
+			}
+			if file == "" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(data)
+		}))
+}
+
+func TestJiraSource(t *testing.T) {
+	server := newJiraTestServer(t)
+	defer server.Close()
+
+	source := NewJiraSource(server.URL+"/", "project = PRJ", DefaultConfig())
+	source.User = "user"
+	source.Token = "secret"
+	source.PageSize = 2
+
+	issues, err := source.Issues()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issues) != 3 {
+		log.Println("TEST: wrong issue count", len(issues))
+		t.FailNow()
+	}
+
+	issue := issues[0]
+	if issue.Key != "PRJ-1" || issue.Id != "10001" ||
+		issue.Type != "Bug" || issue.Status != "Analysis" ||
+		issue.Priority != "High" || issue.Assignee != "dev1" ||
+		issue.Creator != "test1" {
+		log.Println("TEST: wrong system fields", issue.ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+	if issue.Created.Day() != 13 || issue.Due.Day() != 1 ||
+		issue.IsResolved() {
+		log.Println("TEST: wrong dates", issue.ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+	if len(issue.FixVersions) != 2 || len(issue.Labels) != 2 ||
+		len(issue.Components) != 1 || issue.SecurityLevel != "Internal" {
+		log.Println("TEST: wrong lists", issue.ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+	if issue.OriginalEstimate != 8 || issue.TimeSpend != 1.5 {
+		log.Println("TEST: wrong work", issue.ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+	if len(issue.LogWorks) != 1 || issue.LogWorks[0].Activity != "123456" ||
+		issue.LogWorks[0].Author != "dev1" {
+		log.Println("TEST: wrong work logs", issue.ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+	if len(issue.LinkDuplicates) != 1 || issue.LinkDuplicates[0] != "PRJ-2" ||
+		len(issue.LinkBlocks) != 0 {
+		log.Println("TEST: wrong links", issue.ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+	if issue.CustomExternalId != "EXT-42" ||
+		issue.CustomVariant != "Premium" ||
+		issue.CustomActivity != "123456" ||
+		issue.CustomCategory != "Implementation error" {
+		log.Println("TEST: wrong custom fields", issue.ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+
+	issue = issues[1]
+	if !issue.IsResolved() || issue.Resolution != "Fixed" ||
+		issue.Assignee != "" {
+		log.Println("TEST: wrong resolved issue", issue.ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+
+	issue = issues[2]
+	if issue.Parent != "10001" {
+		log.Println("TEST: wrong parent", issue.Parent)
+		t.Fail()
+	}
+	if len(issue.LogWorks) != 2 || issue.LogWorks[1].Hours != 2 {
+		log.Println("TEST: work logs not completed", len(issue.LogWorks))
+		t.Fail()
+	}
+	if len(issue.History) != 1 || issue.History[0].From != "Open" ||
+		issue.History[0].To != "Implementation" ||
+		issue.History[0].Author != "dev2" {
+		log.Println("TEST: wrong history", issue.ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+}
+
+func TestJiraSourceError(t *testing.T) {
+	server := newJiraTestServer(t)
+	defer server.Close()
+
+	source := NewJiraSource(server.URL, "project = PRJ", DefaultConfig())
+	source.User = "user"
+	source.Token = "wrong"
+
+	_, err := source.Issues()
+	if err == nil {
+		log.Println("TEST: missing auth error")
+		t.Fail()
+	}
+}
+
+func TestCustomValueString(t *testing.T) {
+	value := []interface{}{
+		map[string]interface{}{"value": "A"},
+		"B",
+		float64(3),
+	}
+	if customValueString(value) != "A, B, 3" {
+		log.Println("TEST: wrong value", customValueString(value))
+		t.Fail()
+	}
+}
+
+func TestJiraSourceContext(t *testing.T) {
+	server := newJiraTestServer(t)
+	defer server.Close()
+
+	source := NewJiraSource(server.URL, "project = PRJ", DefaultConfig())
+	source.User = "user"
+	source.Token = "secret"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := source.IssuesContext(ctx)
+	if err == nil {
+		log.Println("TEST: canceled request without error")
+		t.Fail()
+	}
+}
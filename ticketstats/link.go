@@ -0,0 +1,148 @@
+package ticketstats
+
+import (
+	"sort"
+)
+
+// Directions of the issue links.
+const (
+	LinkOutward = "outward"
+	LinkInward  = "inward"
+)
+
+// Jira link type names with a special meaning for the evaluation.
+const (
+	LinkTypeBlocks    = "Blocks"
+	LinkTypeCauses    = "Causes"
+	LinkTypeCloners   = "Cloners"
+	LinkTypeDuplicate = "Duplicate"
+	LinkTypeSplit     = "Issue split"
+	LinkTypePart      = "Part"
+	LinkTypeParent    = "parent"
+)
+
+// inwardLinkPrefix is the prefix of the inward link CSV columns,
+// e.g. "Inward issue link (Blocks)".
+const inwardLinkPrefix = "Inward issue link ("
+
+// IssueLink is a typed and directed link to another issue, e.g. the
+// outward "Blocks" link of A to B means A blocks B, and the inward
+// "Blocks" link of B to A means B is blocked by A.
+type IssueLink struct {
+	Type      string
+	Direction string
+	Key       string
+}
+
+// IssueLink.reverse returns the link as seen from the target issue.
+func (link IssueLink) reverse(key string) IssueLink {
+	direction := LinkInward
+	if link.Direction == LinkInward {
+		direction = LinkOutward
+	}
+	return IssueLink{
+		Type:      link.Type,
+		Direction: direction,
+		Key:       key,
+	}
+}
+
+// LinkGraph is the graph of the links between issues. Each link is known by
+// both ends, even if only one of both issues is contained in the issue set
+// or only one of both contains the link.
+type LinkGraph struct {
+	links map[string][]IssueLink
+}
+
+// NewLinkGraph builds the link graph of the given issues.
+func NewLinkGraph(issues []*Issue) *LinkGraph {
+	graph := &LinkGraph{
+		links: make(map[string][]IssueLink),
+	}
+
+	for _, issue := range issues {
+		links := append(issue.outwardLinks(), issue.Links...)
+		for _, link := range links {
+			graph.add(issue.Key, link)
+			graph.add(link.Key, link.reverse(issue.Key))
+		}
+	}
+
+	for key := range graph.links {
+		links := graph.links[key]
+		sort.SliceStable(links, func(i, j int) bool {
+			if links[i].Type != links[j].Type {
+				return links[i].Type < links[j].Type
+			}
+			if links[i].Direction != links[j].Direction {
+				return links[i].Direction > links[j].Direction
+			}
+			return links[i].Key < links[j].Key
+		})
+	}
+
+	return graph
+}
+
+// LinkGraph.add adds the link of the issue with the given key, if it is
+// not yet known.
+func (graph *LinkGraph) add(key string, link IssueLink) {
+	for _, l := range graph.links[key] {
+		if l == link {
+			return
+		}
+	}
+	graph.links[key] = append(graph.links[key], link)
+}
+
+// LinkGraph.Links returns all links of the issue with the given key.
+func (graph *LinkGraph) Links(key string) []IssueLink {
+	links := make([]IssueLink, 0)
+	return append(links, graph.links[key]...)
+}
+
+// LinkGraph.Outward returns the keys of the outward links of the given
+// type, e.g. the issues blocked by the issue for "Blocks".
+func (graph *LinkGraph) Outward(key string, linkType string) []string {
+	return graph.linked(key, linkType, LinkOutward)
+}
+
+// LinkGraph.Inward returns the keys of the inward links of the given
+// type, e.g. the issues blocking the issue for "Blocks".
+func (graph *LinkGraph) Inward(key string, linkType string) []string {
+	return graph.linked(key, linkType, LinkInward)
+}
+
+// LinkGraph.linked returns the keys of the links with the given type and
+// direction.
+func (graph *LinkGraph) linked(key string, linkType string,
+	direction string) []string {
+	keys := make([]string, 0)
+	for _, link := range graph.links[key] {
+		if link.Type == linkType && link.Direction == direction {
+			keys = append(keys, link.Key)
+		}
+	}
+	return keys
+}
+
+// Issue.LinkedKeys returns the keys of the issue links with the given type
+// and direction.
+func (issue *Issue) LinkedKeys(linkType string, direction string) []string {
+	keys := make([]string, 0)
+	for _, link := range issue.Links {
+		if link.Type == linkType && link.Direction == direction {
+			keys = append(keys, link.Key)
+		}
+	}
+	return keys
+}
+
+// Blockers returns the open issues blocking the given issue, using the
+// links of the issue.
+func Blockers(issue *Issue, issues []*Issue, config Config) []*Issue {
+	keys := issue.LinkedKeys(LinkTypeBlocks, LinkInward)
+	return Filter(issues, func(blocker *Issue) bool {
+		return contains(keys, blocker.Key) && !config.IsClosed(blocker)
+	})
+}
@@ -0,0 +1,104 @@
+package ticketstats
+
+import (
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLinkGraph(t *testing.T) {
+	a := NewIssue()
+	a.Key = "A"
+	a.addOutwardLink(LinkTypeBlocks, "B")
+	a.addOutwardLink(LinkTypeCauses, "X")
+
+	// B contains the same link as inward link, it must be merged
+	b := NewIssue()
+	b.Key = "B"
+	b.addInwardLink(LinkTypeBlocks, "A")
+
+	graph := NewLinkGraph([]*Issue{a, b})
+
+	if len(graph.Links("A")) != 2 {
+		log.Println("TEST: wrong links of A", graph.Links("A"))
+		t.Fail()
+	}
+	blocking := graph.Inward("B", LinkTypeBlocks)
+	if len(blocking) != 1 || blocking[0] != "A" {
+		log.Println("TEST: wrong blockers of B", blocking)
+		t.Fail()
+	}
+	if len(graph.Outward("B", LinkTypeBlocks)) != 0 {
+		log.Println("TEST: B blocks A")
+		t.Fail()
+	}
+
+	// X is not in the issue set, but knows the link
+	causes := graph.Inward("X", LinkTypeCauses)
+	if len(causes) != 1 || causes[0] != "A" {
+		log.Println("TEST: wrong causes of X", causes)
+		t.Fail()
+	}
+}
+
+func TestLinkGraphLegacyLinks(t *testing.T) {
+	a := NewIssue()
+	a.Key = "A"
+	a.LinkParents = append(a.LinkParents, "B")
+
+	graph := NewLinkGraph([]*Issue{a})
+	parents := graph.Inward("B", LinkTypeParent)
+	if len(parents) != 1 || parents[0] != "A" {
+		log.Println("TEST: legacy link not in graph", parents)
+		t.Fail()
+	}
+}
+
+func TestParseInwardLinks(t *testing.T) {
+	data := "Issue key;Status;Outward issue link (Blocks);Inward issue link (Blocks)\n" +
+		"A;Open;;B\n" +
+		"C;Open;A;\n"
+
+	config := DefaultConfig()
+	issues, err := ParseCsv(strings.NewReader(data), config)
+	if err != nil {
+		log.Println("TEST: parse failed", err)
+		t.FailNow()
+	}
+
+	keys := issues[0].LinkedKeys(LinkTypeBlocks, LinkInward)
+	if len(keys) != 1 || keys[0] != "B" {
+		log.Println("TEST: inward link not parsed", issues[0].Links)
+		t.Fail()
+	}
+
+	// the blocking of A by C is only known by C
+	ClusterIssues(issues, config)
+	keys = issues[0].LinkedKeys(LinkTypeBlocks, LinkInward)
+	if len(keys) != 2 || keys[0] != "B" || keys[1] != "C" {
+		log.Println("TEST: links not resolved", issues[0].Links)
+		t.Fail()
+	}
+
+	blockers := Blockers(issues[0], issues, config)
+	if len(blockers) != 1 || blockers[0].Key != "C" {
+		log.Println("TEST: wrong blockers", len(blockers))
+		t.Fail()
+	}
+}
+
+func TestClusterIssuesInwardLinks(t *testing.T) {
+	// the split is only exported by the new issue
+	original := NewIssue()
+	original.Key = "A"
+	split := NewIssue()
+	split.Key = "B"
+	split.addInwardLink(LinkTypeSplit, "A")
+
+	ClusterIssues([]*Issue{original, split}, DefaultConfig())
+
+	if len(original.Childs) != 1 || original.Childs[0].Key != "B" {
+		log.Println("TEST: inward split not clustered", len(original.Childs))
+		t.Fail()
+	}
+}
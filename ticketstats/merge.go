@@ -0,0 +1,90 @@
+package ticketstats
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// MergeSummary groups the numbers of a merge of several issue sets.
+type MergeSummary struct {
+	// number of merged issue sets
+	Sets int
+	// number of issues in all sets
+	Issues int
+	// number of issues after the merge
+	Merged int
+	// number of issues contained in more than one set
+	Duplicates int
+	// number of duplicates replaced by a more recent version
+	Replaced int
+}
+
+// MergeSummary.ToString creates a string representation of the summary.
+func (summary MergeSummary) ToString() string {
+	return fmt.Sprintf("%d issues of %d sets merged to %d issues, "+
+		"%d duplicates, %d replaced by a more recent version",
+		summary.Issues, summary.Sets, summary.Merged, summary.Duplicates,
+		summary.Replaced)
+}
+
+// MergeIssues merges the issue sets by issue key. If an issue is contained
+// in several sets, the version with the most recent Updated value is kept,
+// for equal values the version of the later set. The order of the first
+// occurrence is kept. Issues without key are never merged.
+func MergeIssues(sets ...[]*Issue) ([]*Issue, MergeSummary) {
+	summary := MergeSummary{Sets: len(sets)}
+
+	issues := make([]*Issue, 0)
+	index := make(map[string]int)
+	for _, set := range sets {
+		summary.Issues += len(set)
+		for _, issue := range set {
+			i, ok := index[issue.Key]
+			if !ok || issue.Key == "" {
+				index[issue.Key] = len(issues)
+				issues = append(issues, issue)
+				continue
+			}
+
+			summary.Duplicates++
+			if !issue.Updated.Before(issues[i].Updated) {
+				issues[i] = issue
+				summary.Replaced++
+			}
+		}
+	}
+	summary.Merged = len(issues)
+
+	return issues, summary
+}
+
+// expandPaths expands a comma separated list of paths and glob patterns to
+// the list of matching files.
+func expandPaths(paths string) ([]string, error) {
+	files := make([]string, 0)
+	for _, pattern := range strings.Split(paths, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			// no match, a missing file is reported when it is read
+			matches = append(matches, pattern)
+		}
+		for _, match := range matches {
+			if !contains(files, match) {
+				files = append(files, match)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no CSV export given")
+	}
+	return files, nil
+}
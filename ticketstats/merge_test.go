@@ -0,0 +1,83 @@
+package ticketstats
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeIssues(t *testing.T) {
+	issue := func(key string, summary string, daysAgo int) *Issue {
+		issue := NewIssue()
+		issue.Key = key
+		issue.Summary = summary
+		issue.Updated = testNow.AddDate(0, 0, -daysAgo)
+		return issue
+	}
+
+	first := []*Issue{issue("A", "old", 5), issue("B", "new", 1)}
+	second := []*Issue{issue("A", "new", 2), issue("B", "old", 3),
+		issue("C", "new", 0)}
+
+	issues, summary := MergeIssues(first, second)
+	if len(issues) != 3 || issues[0].Key != "A" || issues[2].Key != "C" {
+		log.Println("TEST: wrong merge order", len(issues))
+		t.FailNow()
+	}
+	for _, issue := range issues {
+		if issue.Summary != "new" {
+			log.Println("TEST: outdated issue kept", issue.Key)
+			t.Fail()
+		}
+	}
+	if summary.Sets != 2 || summary.Issues != 5 || summary.Merged != 3 ||
+		summary.Duplicates != 2 || summary.Replaced != 1 {
+		log.Println("TEST: wrong summary", summary.ToString())
+		t.Fail()
+	}
+}
+
+func TestCsvSourceMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "exports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the exports have different columns
+	files := map[string]string{
+		"export1.csv": "Issue key;Summary;Updated\n" +
+			"PRJ-1;First;01/Nov/21 9:00 AM\n" +
+			"PRJ-2;Second;01/Nov/21 9:00 AM\n",
+		"export2.csv": "Summary;Issue key;Updated;Status\n" +
+			"Second updated;PRJ-2;02/Nov/21 9:00 AM;Closed\n" +
+			"Third;PRJ-3;01/Nov/21 9:00 AM;Open\n",
+	}
+	for name, data := range files {
+		err = ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := NewCsvSource(filepath.Join(dir, "export*.csv"), DefaultConfig())
+	issues, err := source.Issues()
+	if err != nil || len(issues) != 3 {
+		log.Println("TEST: exports not merged", len(issues), err)
+		t.FailNow()
+	}
+	if issues[1].Summary != "Second updated" || issues[1].Status != "Closed" {
+		log.Println("TEST: wrong merged issue", issues[1].ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+
+	source.Path = filepath.Join(dir, "export1.csv") + "," +
+		filepath.Join(dir, "missing.csv")
+	_, err = source.Issues()
+	if err == nil {
+		log.Println("TEST: missing export without error")
+		t.Fail()
+	}
+}
@@ -2,6 +2,8 @@ package ticketstats
 
 import (
 	"encoding/csv"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"strconv"
@@ -9,24 +11,33 @@ import (
 	"time"
 )
 
+// readCsv reads and parses Jira CSV export data.
+func readCsv(r io.Reader) ([][]string, error) {
+	csvReader := csv.NewReader(r)
+	csvReader.Comma = ';'
+	csvReader.LazyQuotes = true
+	csvReader.TrimLeadingSpace = true
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse CSV: %v", err)
+	}
+
+	return records, nil
+}
+
 // readCsvFile reads and parses a csv file from disk.
-func readCsvFile(filePath string) [][]string {
+func readCsvFile(filePath string) ([][]string, error) {
 	f, err := os.Open(filePath)
 	if err != nil {
-		log.Fatal("Unable to read input file "+filePath, err)
+		return nil, fmt.Errorf("unable to read input file: %v", err)
 	}
 	defer f.Close()
 
-	csvReader := csv.NewReader(f)
-	csvReader.Comma = ';'
-	csvReader.LazyQuotes = true
-	csvReader.TrimLeadingSpace = true
-	records, err := csvReader.ReadAll()
+	records, err := readCsv(f)
 	if err != nil {
-		log.Fatal("Unable to parse file as CSV for "+filePath, err)
+		return nil, fmt.Errorf("%s: %v", filePath, err)
 	}
-
-	return records
+	return records, nil
 }
 
 // convertWork converts a Jira work value form the CSV export
@@ -37,23 +48,21 @@ func convertWork(data string) Work {
 		log.Println("ERROR:", err)
 		return 0
 	} else {
-		return Work(float64(secs) / 3600)
+		return secondsToWork(secs)
 	}
 }
 
-// convertWorkLog converts a Jira work log to an Worklog.
-// The expected format is:
-// [some text line(s)]
-// ExecutionActivity:<value used as activity>
-// [some more text line(s)]
-// [last line starting with text];[Date];[user (ignored)];[time spend (seconds)]
-func convertWorkLog(data string, config Config) WorkLog {
-	var hours Work
-	var date time.Time
-	var exAc string
+// secondsToWork converts a Jira work value in seconds to Work.
+func secondsToWork(secs int) Work {
+	return Work(float64(secs) / 3600)
+}
 
-	lines := strings.Split(data, "\n")
-	for _, line := range lines {
+// workLogActivity extracts the activity form a work log comment.
+// The activity is given as line "ExecutionActivity:<value>".
+func workLogActivity(comment string) string {
+	var exAc string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
 		if strings.HasPrefix(line, "ExecutionActivity:") {
 			end := strings.Index(line, ";")
 			if end > 0 {
@@ -63,49 +72,171 @@ func convertWorkLog(data string, config Config) WorkLog {
 			}
 		}
 	}
+	return exAc
+}
 
-	line := lines[len(lines)-1]
-	i := strings.LastIndex(line, ";")
-	hours = convertWork(line[i+1:])
+// WorkLogError describes a rejected work log of the CSV export.
+type WorkLogError struct {
+	// key of the issue
+	Key string
+	// raw work log value
+	Value string
+	// why the work log was rejected
+	Reason string
+}
 
-	// skip user
-	tmp := line[:i]
-	i = strings.LastIndex(tmp, ";")
-	// get date
-	tmp = tmp[:i]
-	i = strings.LastIndex(tmp, ";")
-	date = convertDate(tmp[i+1:], config)
+// WorkLogError.Error creates a string representation of the error.
+func (err *WorkLogError) Error() string {
+	return fmt.Sprintf("invalid work log of %s: %s: %q", err.Key, err.Reason,
+		err.Value)
+}
+
+// ParseWorkLog parses a Jira work log of the CSV export. The expected
+// format is "comment;date;author;seconds", e.g.:
+// [some text line(s)]
+// ExecutionActivity:<value used as activity>
+// [some more text line(s)];[Date];[author];[time spend (seconds)]
+// The comment may contain line breaks and semicolons. If the value is
+// malformed, a *WorkLogError is returned.
+func ParseWorkLog(key string, data string, config Config) (WorkLog, error) {
+	dates, err := newDateParser(config, "")
+	if err != nil {
+		return WorkLog{}, err
+	}
+	return parseWorkLog(key, data, dates)
+}
+
+// parseWorkLog parses a Jira work log using the given date parser, see
+// ParseWorkLog.
+func parseWorkLog(key string, data string, dates *dateParser) (WorkLog, error) {
+	reject := func(reason string) (WorkLog, error) {
+		return WorkLog{}, &WorkLogError{
+			Key:    key,
+			Value:  data,
+			Reason: reason,
+		}
+	}
+
+	// the comment is free text, the other fields are taken from the end
+	fields := strings.Split(data, ";")
+	if len(fields) < 4 {
+		return reject("expected comment;date;author;seconds")
+	}
+	n := len(fields)
+	comment := strings.Join(fields[:n-3], ";")
+
+	date, err := dates.parse(strings.TrimSpace(fields[n-3]))
+	if err != nil {
+		return reject("invalid date")
+	}
+
+	author := strings.TrimSpace(fields[n-2])
+	if author == "" {
+		return reject("missing author")
+	}
+
+	secs, err := strconv.Atoi(strings.TrimSpace(fields[n-1]))
+	if err != nil || secs < 0 {
+		return reject("invalid time spent")
+	}
 
 	return WorkLog{
-		Hours:    hours,
+		Hours:    secondsToWork(secs),
 		Date:     date,
-		Activity: exAc,
+		Activity: workLogActivity(comment),
+		Author:   author,
+		Comment:  strings.TrimSpace(comment),
+	}, nil
+}
+
+// Issue.parseWorkLogs parses the Jira work logs of the CSV export. Malformed
+// work logs are logged and added to RejectedWorkLogs.
+func (issue *Issue) parseWorkLogs(values []string, dates *dateParser) {
+	for _, value := range values {
+		workLog, err := parseWorkLog(issue.Key, value, dates)
+		if err != nil {
+			log.Println("ERROR:", err)
+			issue.RejectedWorkLogs = append(issue.RejectedWorkLogs,
+				err.(*WorkLogError))
+			continue
+		}
+		issue.LogWorks = append(issue.LogWorks, workLog)
 	}
 }
 
-// convertDate converts a date string to a time.Time.
-// The expected format is "02/Jan/06 3:04 PM".
-func convertDate(data string, config Config) time.Time {
-	t, err := time.Parse(config.Formats.JiraDate, data)
+// outwardLinkPrefix is the start of the CSV column names for outward links,
+// e.g. "Outward issue link (Blocks)".
+const outwardLinkPrefix = "Outward issue link ("
+
+// Issue.parseDate parses a date of the CSV export. Unparseable dates are
+// added to DateErrors and result in the zero time.
+func (issue *Issue) parseDate(dates *dateParser, column string,
+	data string) time.Time {
+	t, err := dates.parse(data)
 	if err != nil {
-		log.Println("ERROR:", err)
+		issue.DateErrors = append(issue.DateErrors, &DateError{
+			Key:    issue.Key,
+			Column: column,
+			Value:  data,
+		})
 		return time.Time{}
 	}
 	return t
 }
 
 // Parse parse the CSV data form path as a list if issues.
-// This function maps the Jira CSV records to the internal
-// Issue data objects.
+// Errors are logged and an empty list is returned, use ParseFile to handle
+// the errors.
 func Parse(path string, config Config) []*Issue {
-	records := readCsvFile(path)
+	issues, err := ParseFile(path, config)
+	if err != nil {
+		log.Println("ERROR:", err)
+		return make([]*Issue, 0)
+	}
+	return issues
+}
 
-	header := records[0]
+// ParseFile parses the CSV export at path as a list of issues.
+func ParseFile(path string, config Config) ([]*Issue, error) {
+	records, err := readCsvFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseRecords(records, config)
+}
+
+// ParseCsv parses the CSV export data read from r as a list of issues.
+func ParseCsv(r io.Reader, config Config) ([]*Issue, error) {
+	records, err := readCsv(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseRecords(records, config)
+}
+
+// parseRecords maps the Jira CSV records to the internal Issue data
+// objects. The first record is the header.
+func parseRecords(records [][]string, config Config) ([]*Issue, error) {
+	if len(records) == 0 {
+		return nil, fmt.Errorf("CSV export without header")
+	}
+
+	header, err := resolveHeader(records[0], config)
+	if err != nil {
+		return nil, err
+	}
 	data := records[1:]
 	issues := make([]*Issue, 0)
 
+	layout := detectDateLayout(header, data, config)
+	dates, err := newDateParser(config, layout)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, d := range data {
 		issue := NewIssue()
+		workLogs := make([]string, 0)
 		for i, val := range d {
 			val = strings.TrimSpace(val)
 
@@ -136,11 +267,11 @@ func Parse(path string, config Config) []*Issue {
 			case "Creator":
 				issue.Creator = val
 			case "Created":
-				issue.Created = convertDate(val, config)
+				issue.Created = issue.parseDate(dates, key, val)
 			case "Updated":
-				issue.Updated = convertDate(val, config)
+				issue.Updated = issue.parseDate(dates, key, val)
 			case "Last Viewed":
-				issue.LastViewed = convertDate(val, config)
+				issue.LastViewed = issue.parseDate(dates, key, val)
 			case "Affects Version/s":
 				issue.AffectsVersions = append(issue.AffectsVersions, val)
 			case "Fix Version/s":
@@ -148,8 +279,8 @@ func Parse(path string, config Config) []*Issue {
 			case "Component/s":
 				issue.Components = append(issue.Components, val)
 			case "Log Work":
-				issue.LogWorks = append(issue.LogWorks,
-					convertWorkLog(val, config))
+				// parsed after the row, the issue key is needed for errors
+				workLogs = append(workLogs, val)
 			case "Original Estimate":
 				issue.OriginalEstimate = convertWork(val)
 			case "Remaining Estimate":
@@ -169,46 +300,30 @@ func Parse(path string, config Config) []*Issue {
 			case "Resolution":
 				issue.Resolution = val
 			case "Resolved":
-				issue.Resolved = convertDate(val, config)
+				issue.Resolved = issue.parseDate(dates, key, val)
 			case "Due Date":
-				issue.Due = convertDate(val, config)
-			case "Outward issue link (Blocks)":
-				issue.LinkBlocks = append(issue.LinkBlocks, val)
-			case "Outward issue link (Causes)":
-				issue.LinkCauses = append(issue.LinkCauses, val)
-			case "Outward issue link (Cloners)":
-				issue.LinkCloners = append(issue.LinkCloners, val)
-			case "Outward issue link (Dependency)":
-				issue.LinkDependencies = append(issue.LinkDependencies, val)
-			case "Outward issue link (Duplicate)":
-				issue.LinkDuplicates = append(issue.LinkDuplicates, val)
-			case "Outward issue link (Issue split)":
-				issue.LinkIssueSplits = append(issue.LinkIssueSplits, val)
-			case "Outward issue link (Part)":
-				issue.LinkParts = append(issue.LinkParts, val)
-			case "Outward issue link (Relates)":
-				issue.LinkRelates = append(issue.LinkRelates, val)
-			case "Outward issue link (Relation)":
-				issue.LinkRelations = append(issue.LinkRelations, val)
-			case "Outward issue link (Triggers)":
-				issue.LinkTriggers = append(issue.LinkTriggers, val)
-			case "Outward issue link (linkIssue)":
-				issue.LinkLinkIssues = append(issue.LinkLinkIssues, val)
-			case "Outward issue link (parent)":
-				issue.LinkParents = append(issue.LinkParents, val)
-			case config.Customs.ExternalId:
-				issue.CustomExternalId = val
-			case config.Customs.SupplierReference:
-				issue.CustomSupplierRef = val
-			case config.Customs.Variant:
-				issue.CustomVariant = val
-			case config.Customs.Account:
-				issue.CustomActivity = val
-			case config.Customs.Category:
-				issue.CustomCategory = val
+				issue.Due = issue.parseDate(dates, key, val)
+			default:
+				if strings.HasPrefix(key, outwardLinkPrefix) &&
+					strings.HasSuffix(key, ")") {
+					issue.addOutwardLink(
+						key[len(outwardLinkPrefix):len(key)-1], val)
+				} else if strings.HasPrefix(key, inwardLinkPrefix) &&
+					strings.HasSuffix(key, ")") {
+					issue.addInwardLink(
+						key[len(inwardLinkPrefix):len(key)-1], val)
+				} else {
+					issue.setCustomField(key, val, config)
+				}
 			}
 		}
+		issue.parseWorkLogs(workLogs, dates)
+		// the issue key may follow the date columns
+		for _, err := range issue.DateErrors {
+			err.Key = issue.Key
+			log.Println("ERROR:", err)
+		}
 		issues = append(issues, issue)
 	}
-	return issues
+	return issues, nil
 }
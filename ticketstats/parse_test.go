@@ -2,6 +2,7 @@ package ticketstats
 
 import (
 	"log"
+	"strings"
 	"testing"
 )
 
@@ -11,33 +12,123 @@ func TestConvertWork(t *testing.T) {
 	}
 }
 
-func TestConvertWorkLog(t *testing.T) {
+func TestParseWorkLog(t *testing.T) {
 	config := DefaultConfig()
 	// Log Work = [entry description (containing ExAc as
 	// ExecutionActivity:XXXXXX)];[Date];[user (always asw_qm_service)];
 	// [time spend as seconds]
 	data := "Hallo Welt\nExecutionActivity:123457\nmore text;" +
 		"31/Aug/21 12:57 PM;aUser;45000"
-	work := convertWorkLog(data, DefaultConfig())
+	work, err := ParseWorkLog("PRJ-1", data, config)
+	if err != nil {
+		log.Println("TEST: work log rejected", err)
+		t.FailNow()
+	}
 
 	if work.Activity != "123457" {
-		log.Println("TEST: activity wrong", work.ToString(config))
+		log.Println("TEST: activity wrong", work.ToString(config, testCalendar))
 		t.Fail()
 	}
 
 	if work.Hours != 12.5 {
-		log.Println("TEST: hours", work.ToString(config))
+		log.Println("TEST: hours", work.ToString(config, testCalendar))
+		t.Fail()
+	}
+
+	dates, err := newDateParser(config, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date, err := dates.parse("31/Aug/21 12:57 PM")
+	if err != nil || work.Date != date {
+		log.Println("TEST: date", work.ToString(config, testCalendar))
 		t.Fail()
 	}
 
-	if work.Date != convertDate("31/Aug/21 12:57 PM", DefaultConfig()) {
-		log.Println("TEST: date", work.ToString(config))
+	if work.Author != "aUser" {
+		log.Println("TEST: author", work.ToString(config, testCalendar))
 		t.Fail()
 	}
 }
 
-func TestConvertDate(t *testing.T) {
-	date := convertDate("31/Aug/21 3:57 PM", DefaultConfig())
+func TestParseWorkLogSemicolons(t *testing.T) {
+	data := "fixed a; b and c\nExecutionActivity:123457;" +
+		"31/Aug/21 12:57 PM;aUser;3600"
+	work, err := ParseWorkLog("PRJ-1", data, DefaultConfig())
+	if err != nil || work.Hours != 1 || work.Activity != "123457" ||
+		work.Author != "aUser" {
+		log.Println("TEST: comment with semicolons", work, err)
+		t.Fail()
+	}
+}
+
+func TestParseWorkLogErrors(t *testing.T) {
+	invalid := map[string]string{
+		"":                                   "expected comment;date;author;seconds",
+		"31/Aug/21 12:57 PM;aUser;3600":      "expected comment;date;author;seconds",
+		"text;yesterday;aUser;3600":          "invalid date",
+		"text;31/Aug/21 12:57 PM;;3600":      "missing author",
+		"text;31/Aug/21 12:57 PM;aUser;1h":   "invalid time spent",
+		"text;31/Aug/21 12:57 PM;aUser;-600": "invalid time spent",
+	}
+
+	for data, reason := range invalid {
+		_, err := ParseWorkLog("PRJ-1", data, DefaultConfig())
+		wlErr, ok := err.(*WorkLogError)
+		if !ok {
+			log.Println("TEST: work log not rejected", data)
+			t.Fail()
+			continue
+		}
+		if wlErr.Key != "PRJ-1" || wlErr.Value != data ||
+			wlErr.Reason != reason {
+			log.Println("TEST: wrong error", wlErr)
+			t.Fail()
+		}
+	}
+}
+
+func TestParseCsvRejectedWorkLogs(t *testing.T) {
+	// the work log columns are before the key column
+	data := "Log Work;Log Work;Issue key;Status\n" +
+		"\"ok;31/Aug/21 12:57 PM;aUser;3600\";broken;PRJ-1;Closed\n"
+
+	config := DefaultConfig()
+	issues, err := ParseCsv(strings.NewReader(data), config)
+	if err != nil || len(issues) != 1 {
+		log.Println("TEST: parse failed", err)
+		t.FailNow()
+	}
+
+	issue := issues[0]
+	if len(issue.LogWorks) != 1 || len(issue.RejectedWorkLogs) != 1 ||
+		issue.RejectedWorkLogs[0].Key != "PRJ-1" {
+		log.Println("TEST: wrong work logs", issue.ToString(config, testCalendar))
+		t.FailNow()
+	}
+
+	result := Sanitize(issues, false, testNow, config)
+	warnings := result.ToWarnings("", testNow, config,
+		testCalendar)
+	if warnings.Count != 1 || len(warnings.RejectedWorkLogs) != 1 ||
+		warnings.RejectedWorkLogs[0].Value != "broken" {
+		log.Println("TEST: rejected work log not in warnings",
+			warnings.Count)
+		t.Fail()
+	}
+}
+
+func TestDateParser(t *testing.T) {
+	dates, err := newDateParser(DefaultConfig(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	date, err := dates.parse("31/Aug/21 3:57 PM")
+	if err != nil {
+		log.Println("TEST: date rejected", err)
+		t.FailNow()
+	}
+
 	if date.Year() != 2021 {
 		log.Println("TEST: year", date)
 		t.Fail()
@@ -63,3 +154,31 @@ func TestConvertDate(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestParseCsv(t *testing.T) {
+	data := "Summary;Issue key;Issue Type\n" +
+		"A bug;PRJ-1;Bug\n" +
+		"A feature;PRJ-2;New Feature\n"
+
+	issues, err := ParseCsv(strings.NewReader(data), DefaultConfig())
+	if err != nil || len(issues) != 2 {
+		log.Println("TEST: wrong issues", err)
+		t.FailNow()
+	}
+	if issues[1].Key != "PRJ-2" || issues[1].Type != "New Feature" {
+		log.Println("TEST: wrong issue", issues[1].ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+
+	_, err = ParseCsv(strings.NewReader(""), DefaultConfig())
+	if err == nil {
+		log.Println("TEST: empty data without error")
+		t.Fail()
+	}
+
+	_, err = ParseFile("missing.csv", DefaultConfig())
+	if err == nil {
+		log.Println("TEST: missing file without error")
+		t.Fail()
+	}
+}
@@ -0,0 +1,177 @@
+package ticketstats
+
+import (
+	"strings"
+	"time"
+)
+
+// Predicate tests an issue. Predicates are combined using And, Or and Not
+// and evaluated in a single pass using Filter or Select, e.g.
+//
+//	Select(issues, ByType("Bug"), Not(ByLabel("legacy")))
+type Predicate func(issue *Issue) bool
+
+// Select returns all issues matching all predicates. The issues are
+// evaluated in a single pass.
+func Select(issues []*Issue, predicates ...Predicate) []*Issue {
+	return Filter(issues, And(predicates...))
+}
+
+// And returns a predicate matching if all predicates match. Without
+// predicates, all issues match.
+func And(predicates ...Predicate) Predicate {
+	predicates = append([]Predicate{}, predicates...)
+	return func(issue *Issue) bool {
+		for _, predicate := range predicates {
+			if !predicate(issue) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a predicate matching if one of the predicates matches. Without
+// predicates, no issue matches.
+func Or(predicates ...Predicate) Predicate {
+	predicates = append([]Predicate{}, predicates...)
+	return func(issue *Issue) bool {
+		for _, predicate := range predicates {
+			if predicate(issue) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a predicate matching if the predicate doesn't match.
+func Not(predicate Predicate) Predicate {
+	return func(issue *Issue) bool {
+		return !predicate(issue)
+	}
+}
+
+// ByType matches the issues of the given type.
+func ByType(ticketType string) Predicate {
+	return func(issue *Issue) bool {
+		return issue.Type == ticketType
+	}
+}
+
+// ByLabel matches the issues with the given label.
+func ByLabel(label string) Predicate {
+	return func(issue *Issue) bool {
+		return contains(issue.Labels, label)
+	}
+}
+
+// ByComponent matches the issues of the given component.
+func ByComponent(component string) Predicate {
+	return func(issue *Issue) bool {
+		return contains(issue.Components, component)
+	}
+}
+
+// ByFixVersion matches the issues with the given fix version.
+func ByFixVersion(fixVersion string) Predicate {
+	return func(issue *Issue) bool {
+		return contains(issue.FixVersions, fixVersion)
+	}
+}
+
+// BySecurityLevel matches the issues with the given security level.
+func BySecurityLevel(securityLevel string) Predicate {
+	return func(issue *Issue) bool {
+		return issue.SecurityLevel == securityLevel
+	}
+}
+
+// ByPriority matches the issues with the given priority.
+func ByPriority(priority string) Predicate {
+	return func(issue *Issue) bool {
+		return issue.Priority == priority
+	}
+}
+
+// ByProject matches the issues with a key starting with the given project
+// key.
+func ByProject(project string) Predicate {
+	return func(issue *Issue) bool {
+		return strings.HasPrefix(issue.Key, project)
+	}
+}
+
+// ByStatus matches the issues in one of the given states.
+func ByStatus(statuses ...string) Predicate {
+	return func(issue *Issue) bool {
+		for _, status := range statuses {
+			if issue.Status == status {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ByStatusCategory matches the issues with a state of the given category,
+// see config.States.Categories.
+func ByStatusCategory(category string, config Config) Predicate {
+	return func(issue *Issue) bool {
+		return config.StatusCategory(issue) == category
+	}
+}
+
+// ByField matches the issues having the given value for the generic custom
+// field. For list fields, one of the values must match.
+func ByField(name string, value string, config Config) Predicate {
+	return func(issue *Issue) bool {
+		v, ok := issue.Field(name)
+		return ok && contains(v.Values(config), value)
+	}
+}
+
+// IsOpen matches the issues with no resolution date and a state not in the
+// category done.
+func IsOpen(config Config) Predicate {
+	return func(issue *Issue) bool {
+		return issue.Resolved.IsZero() && !config.IsClosed(issue)
+	}
+}
+
+// IsActive matches the open issues and the issues updated during the month
+// before now.
+func IsActive(now time.Time, config Config) Predicate {
+	return Or(IsOpen(config), UpdatedWithin(Window{
+		Start: now.AddDate(0, -1, 0),
+		End:   now,
+	}))
+}
+
+// CreatedWithin matches the issues created within the window.
+func CreatedWithin(window Window) Predicate {
+	return func(issue *Issue) bool {
+		return window.Contains(issue.Created)
+	}
+}
+
+// ClosedWithin matches the issues resolved within the window.
+func ClosedWithin(window Window) Predicate {
+	return func(issue *Issue) bool {
+		return window.Contains(issue.Resolved)
+	}
+}
+
+// UpdatedWithin matches the issues updated within the window.
+func UpdatedWithin(window Window) Predicate {
+	return func(issue *Issue) bool {
+		return window.Contains(issue.Updated)
+	}
+}
+
+// CreatedBefore matches the issues created before the date.
+func CreatedBefore(date time.Time) Predicate {
+	return func(issue *Issue) bool {
+		return issue.Created.Before(date)
+	}
+}
@@ -0,0 +1,101 @@
+package ticketstats
+
+import (
+	"log"
+	"testing"
+)
+
+func predicateTestIssues() []*Issue {
+	issues := make([]*Issue, 0)
+
+	issue := NewIssue()
+	issue.Key = "PRJ-1"
+	issue.Type = "Bug"
+	issue.Status = "Open"
+	issue.Labels = append(issue.Labels, "legacy")
+	issue.Created = testNow.AddDate(0, 0, -3)
+	issues = append(issues, issue)
+
+	issue = NewIssue()
+	issue.Key = "PRJ-2"
+	issue.Type = "Bug"
+	issue.Status = "Review"
+	issue.Created = testNow.AddDate(0, -2, 0)
+	issues = append(issues, issue)
+
+	issue = NewIssue()
+	issue.Key = "OTHER-1"
+	issue.Type = "New Feature"
+	issue.Status = "Closed"
+	issue.Created = testNow.AddDate(0, 0, -20)
+	issue.Resolved = testNow.AddDate(0, 0, -2)
+	issue.Updated = testNow.AddDate(0, 0, -2)
+	issues = append(issues, issue)
+
+	return issues
+}
+
+func predicateKeys(issues []*Issue) string {
+	keys := ""
+	for _, issue := range issues {
+		keys += issue.Key + " "
+	}
+	return keys
+}
+
+func TestPredicates(t *testing.T) {
+	issues := predicateTestIssues()
+	config := DefaultConfig()
+	lastWeek := Window{Start: testNow.AddDate(0, 0, -7), End: testNow}
+
+	expected := map[string]Predicate{
+		"PRJ-1 PRJ-2 OTHER-1 ": And(),
+		"":                     Or(),
+		"PRJ-1 ":               And(ByType("Bug"), ByLabel("legacy")),
+		"PRJ-2 OTHER-1 ":       Not(ByLabel("legacy")),
+		"PRJ-1 OTHER-1 ": Or(CreatedWithin(lastWeek),
+			ByType("New Feature")),
+		"PRJ-2 ":   ByStatusCategory(CategoryReview, config),
+		"OTHER-1 ": And(ClosedWithin(lastWeek), ByProject("OTHER")),
+		"PRJ-1 PRJ-2 ": And(IsOpen(config),
+			Not(ByStatus("Verification", "Closed"))),
+	}
+	for keys, predicate := range expected {
+		result := predicateKeys(Filter(issues, predicate))
+		if result != keys {
+			log.Println("TEST: wrong predicate result", result, "expected",
+				keys)
+			t.Fail()
+		}
+	}
+
+	active := predicateKeys(Filter(issues, IsActive(testNow, config)))
+	if active != "PRJ-1 PRJ-2 OTHER-1 " {
+		log.Println("TEST: wrong active tickets", active)
+		t.Fail()
+	}
+}
+
+func TestSelectSinglePass(t *testing.T) {
+	issues := predicateTestIssues()
+
+	calls := 0
+	counting := func(predicate Predicate) Predicate {
+		return func(issue *Issue) bool {
+			calls++
+			return predicate(issue)
+		}
+	}
+
+	result := Select(issues, counting(ByType("Bug")),
+		counting(Not(ByLabel("legacy"))))
+	if predicateKeys(result) != "PRJ-2 " {
+		log.Println("TEST: wrong selection", predicateKeys(result))
+		t.Fail()
+	}
+	// the second predicate is only evaluated for the two bugs
+	if calls != 5 {
+		log.Println("TEST: wrong number of predicate calls", calls)
+		t.Fail()
+	}
+}
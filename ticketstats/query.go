@@ -0,0 +1,740 @@
+package ticketstats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Value kinds of the query fields.
+const (
+	queryText = iota
+	queryList
+	queryDate
+	queryNumber
+)
+
+// queryField describes a field of the query language. Depending on the kind,
+// one of the value functions is set.
+type queryField struct {
+	kind   int
+	text   func(issue *Issue) string
+	list   func(issue *Issue) []string
+	date   func(issue *Issue) time.Time
+	number func(issue *Issue) (float64, bool)
+}
+
+// queryFields are the built-in fields by lower case name.
+var queryFields = map[string]queryField{
+	"key": {kind: queryText,
+		text: func(issue *Issue) string { return issue.Key }},
+	"project": {kind: queryText,
+		text: func(issue *Issue) string { return projectKey(issue.Key) }},
+	"summary": {kind: queryText,
+		text: func(issue *Issue) string { return issue.Summary }},
+	"type": {kind: queryText,
+		text: func(issue *Issue) string { return issue.Type }},
+	"status": {kind: queryText,
+		text: func(issue *Issue) string { return issue.Status }},
+	"priority": {kind: queryText,
+		text: func(issue *Issue) string { return issue.Priority }},
+	"assignee": {kind: queryText,
+		text: func(issue *Issue) string { return issue.Assignee }},
+	"creator": {kind: queryText,
+		text: func(issue *Issue) string { return issue.Creator }},
+	"resolution": {kind: queryText,
+		text: func(issue *Issue) string { return issue.Resolution }},
+	"securitylevel": {kind: queryText,
+		text: func(issue *Issue) string { return issue.SecurityLevel }},
+	"activity": {kind: queryText,
+		text: func(issue *Issue) string { return issue.CustomActivity }},
+	"labels": {kind: queryList,
+		list: func(issue *Issue) []string { return issue.Labels }},
+	"components": {kind: queryList,
+		list: func(issue *Issue) []string { return issue.Components }},
+	"fixversion": {kind: queryList,
+		list: func(issue *Issue) []string { return issue.FixVersions }},
+	"affectedversion": {kind: queryList,
+		list: func(issue *Issue) []string { return issue.AffectsVersions }},
+	"created": {kind: queryDate,
+		date: func(issue *Issue) time.Time { return issue.Created }},
+	"updated": {kind: queryDate,
+		date: func(issue *Issue) time.Time { return issue.Updated }},
+	"resolved": {kind: queryDate,
+		date: func(issue *Issue) time.Time { return issue.Resolved }},
+	"due": {kind: queryDate,
+		date: func(issue *Issue) time.Time { return issue.Due }},
+	"estimate": {kind: queryNumber,
+		number: func(issue *Issue) (float64, bool) {
+			return float64(issue.OriginalEstimate), true
+		}},
+	"remaining": {kind: queryNumber,
+		number: func(issue *Issue) (float64, bool) {
+			return float64(issue.RemainingEstimate), true
+		}},
+	"timespent": {kind: queryNumber,
+		number: func(issue *Issue) (float64, bool) {
+			return float64(issue.TimeSpend), true
+		}},
+}
+
+// queryAliases maps alternative field names to the built-in fields.
+var queryAliases = map[string]string{
+	"issuetype":        "type",
+	"label":            "labels",
+	"component":        "components",
+	"fixversions":      "fixversion",
+	"affectsversion":   "affectedversion",
+	"affectsversions":  "affectedversion",
+	"security":         "securitylevel",
+	"resolutiondate":   "resolved",
+	"duedate":          "due",
+	"originalestimate": "estimate",
+	"timespend":        "timespent",
+}
+
+// projectKey returns the project key of an issue key, e.g. "PRJ" of
+// "PRJ-12".
+func projectKey(key string) string {
+	i := strings.LastIndex(key, "-")
+	if i < 0 {
+		return key
+	}
+	return key[:i]
+}
+
+// lookupQueryField returns the field of the given name. Besides the
+// built-in fields, the generic custom fields of the config can be used by
+// name, e.g. "Story Points".
+func lookupQueryField(name string, config Config) (queryField, bool) {
+	lower := strings.ToLower(name)
+	if alias, ok := queryAliases[lower]; ok {
+		lower = alias
+	}
+	if field, ok := queryFields[lower]; ok {
+		return field, true
+	}
+	if strings.ToLower(name) == "statuscategory" ||
+		strings.ToLower(name) == "category" {
+		return queryField{kind: queryText,
+			text: func(issue *Issue) string {
+				return config.StatusCategory(issue)
+			}}, true
+	}
+
+	for column, fieldType := range config.Customs.Fields {
+		fieldName := FieldName(column)
+		if !strings.EqualFold(fieldName, name) {
+			continue
+		}
+		switch fieldType {
+		case FieldNumber:
+			return queryField{kind: queryNumber,
+				number: func(issue *Issue) (float64, bool) {
+					value, ok := issue.Field(fieldName)
+					return value.Number, ok
+				}}, true
+		case FieldDate:
+			return queryField{kind: queryDate,
+				date: func(issue *Issue) time.Time {
+					value, _ := issue.Field(fieldName)
+					return value.Date
+				}}, true
+		}
+		return queryField{kind: queryList,
+			list: func(issue *Issue) []string {
+				value, ok := issue.Field(fieldName)
+				if !ok {
+					return []string{}
+				}
+				return value.Values(config)
+			}}, true
+	}
+
+	return queryField{}, false
+}
+
+// Token kinds of the query lexer.
+const (
+	tokenWord = iota
+	tokenString
+	tokenOperator
+	tokenOpen
+	tokenClose
+	tokenComma
+	tokenEnd
+)
+
+// queryToken is a token of a query expression.
+type queryToken struct {
+	kind int
+	text string
+	// position of the token in the expression
+	pos int
+}
+
+// queryToken.is tests if the token is the given keyword. Keywords are case
+// insensitive and never quoted.
+func (token queryToken) is(keyword string) bool {
+	return token.kind == tokenWord && strings.EqualFold(token.text, keyword)
+}
+
+// isQuerySpecial tests if the rune ends a word of the query.
+func isQuerySpecial(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune("()=,!<>~\"'", r)
+}
+
+// tokenizeQuery splits the query expression into tokens.
+func tokenizeQuery(expr string) ([]queryToken, error) {
+	tokens := make([]queryToken, 0)
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, queryToken{tokenOpen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{tokenClose, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, queryToken{tokenComma, ",", i})
+			i++
+		case r == '"' || r == '\'':
+			start := i
+			value := make([]rune, 0)
+			i++
+			for i < len(runes) && runes[i] != r {
+				// backslash escapes the quote
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value = append(value, runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d",
+					start)
+			}
+			tokens = append(tokens, queryToken{tokenString, string(value),
+				start})
+			i++
+		case strings.ContainsRune("=!<>~", r):
+			start := i
+			op := string(r)
+			if i+1 < len(runes) && strings.ContainsRune("=~", runes[i+1]) &&
+				r != '=' && r != '~' {
+				op += string(runes[i+1])
+			}
+			switch op {
+			case "=", "!=", "~", "!~", "<", "<=", ">", ">=":
+			default:
+				return nil, fmt.Errorf("invalid operator %q at position %d",
+					op, start)
+			}
+			tokens = append(tokens, queryToken{tokenOperator, op, start})
+			i += len(op)
+		default:
+			start := i
+			for i < len(runes) && !isQuerySpecial(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, queryToken{tokenWord,
+				string(runes[start:i]), start})
+		}
+	}
+
+	return append(tokens, queryToken{tokenEnd, "", len(runes)}), nil
+}
+
+// queryParser is a recursive descent parser of query expressions.
+type queryParser struct {
+	tokens []queryToken
+	next   int
+	now    time.Time
+	config Config
+}
+
+// queryParser.peek returns the next token without consuming it.
+func (parser *queryParser) peek() queryToken {
+	return parser.tokens[parser.next]
+}
+
+// queryParser.take consumes and returns the next token.
+func (parser *queryParser) take() queryToken {
+	token := parser.tokens[parser.next]
+	if token.kind != tokenEnd {
+		parser.next++
+	}
+	return token
+}
+
+// queryParser.errorf creates a parse error at the position of the token.
+func (parser *queryParser) errorf(token queryToken, format string,
+	v ...interface{}) error {
+	if token.kind == tokenEnd {
+		return fmt.Errorf(format+" at end of filter", v...)
+	}
+	return fmt.Errorf(format+" at position %d", append(v, token.pos)...)
+}
+
+// ParseQuery compiles a filter expression to a Predicate.
+// The expression language is similar to JQL, e.g.
+//
+//	type = Bug AND priority in (Blocker, Critical) AND labels != legacy
+//	AND created > -30d
+//
+// Relative dates like -30d are relative to now, absolute dates use the
+// format config.Formats.Date.
+func ParseQuery(expr string, now time.Time,
+	config Config) (Predicate, error) {
+	tokens, err := tokenizeQuery(expr)
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %v", expr, err)
+	}
+
+	parser := &queryParser{
+		tokens: tokens,
+		now:    now,
+		config: config,
+	}
+	if parser.peek().kind == tokenEnd {
+		// an empty filter keeps all issues
+		return And(), nil
+	}
+
+	test, err := parser.parseOr()
+	if err == nil && parser.peek().kind != tokenEnd {
+		err = parser.errorf(parser.peek(), "unexpected %q", parser.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("filter %q: %v", expr, err)
+	}
+	return test, nil
+}
+
+// FilterByQuery reduces the issues to the ones matching the filter
+// expression, see ParseQuery.
+func FilterByQuery(issues []*Issue, expr string, now time.Time,
+	config Config) ([]*Issue, error) {
+	test, err := ParseQuery(expr, now, config)
+	if err != nil {
+		return nil, err
+	}
+	return Filter(issues, test), nil
+}
+
+// queryParser.parseOr parses terms joined by OR.
+func (parser *queryParser) parseOr() (Predicate, error) {
+	left, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for parser.peek().is("OR") {
+		parser.take()
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or(left, right)
+	}
+	return left, nil
+}
+
+// queryParser.parseAnd parses terms joined by AND.
+func (parser *queryParser) parseAnd() (Predicate, error) {
+	left, err := parser.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for parser.peek().is("AND") {
+		parser.take()
+		right, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = And(left, right)
+	}
+	return left, nil
+}
+
+// queryParser.parseNot parses a negated term, a term in parentheses or a
+// comparison.
+func (parser *queryParser) parseNot() (Predicate, error) {
+	token := parser.peek()
+	switch {
+	case token.is("NOT"):
+		parser.take()
+		test, err := parser.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return Not(test), nil
+	case token.kind == tokenOpen:
+		parser.take()
+		test, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if parser.peek().kind != tokenClose {
+			return nil, parser.errorf(parser.peek(), "missing \")\"")
+		}
+		parser.take()
+		return test, nil
+	}
+	return parser.parseComparison()
+}
+
+// queryParser.parseComparison parses a comparison of a field, i.e.
+// "field op value", "field [not] in (values)" or "field is [not] empty".
+func (parser *queryParser) parseComparison() (Predicate, error) {
+	token := parser.take()
+	if token.kind != tokenWord && token.kind != tokenString {
+		return nil, parser.errorf(token, "expected field, found %q",
+			token.text)
+	}
+	field, ok := lookupQueryField(token.text, parser.config)
+	if !ok {
+		return nil, parser.errorf(token, "unknown field %q", token.text)
+	}
+
+	op := parser.take()
+	switch {
+	case op.is("IS"):
+		negate := false
+		if parser.peek().is("NOT") {
+			parser.take()
+			negate = true
+		}
+		if !parser.peek().is("EMPTY") {
+			return nil, parser.errorf(parser.peek(), "expected EMPTY")
+		}
+		parser.take()
+		test := emptyTest(field)
+		if negate {
+			return Not(test), nil
+		}
+		return test, nil
+	case op.is("IN"), op.is("NOT") && parser.peek().is("IN"):
+		if op.is("NOT") {
+			parser.take()
+		}
+		values, err := parser.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		test, err := parser.compare(field, "=", values, token)
+		if err != nil {
+			return nil, err
+		}
+		if op.is("NOT") {
+			return Not(test), nil
+		}
+		return test, nil
+	case op.kind == tokenOperator:
+		value := parser.take()
+		if value.kind != tokenWord && value.kind != tokenString {
+			return nil, parser.errorf(value, "expected value, found %q",
+				value.text)
+		}
+		return parser.compare(field, op.text, []queryToken{value}, token)
+	}
+	return nil, parser.errorf(op, "expected operator, found %q", op.text)
+}
+
+// queryParser.parseValues parses a value list in parentheses.
+func (parser *queryParser) parseValues() ([]queryToken, error) {
+	if parser.peek().kind != tokenOpen {
+		return nil, parser.errorf(parser.peek(), "expected \"(\"")
+	}
+	parser.take()
+
+	values := make([]queryToken, 0)
+	for {
+		value := parser.take()
+		if value.kind != tokenWord && value.kind != tokenString {
+			return nil, parser.errorf(value, "expected value, found %q",
+				value.text)
+		}
+		values = append(values, value)
+
+		next := parser.take()
+		if next.kind == tokenClose {
+			return values, nil
+		}
+		if next.kind != tokenComma {
+			return nil, parser.errorf(next, "expected \",\" or \")\"")
+		}
+	}
+}
+
+// emptyTest returns the test for "is empty" of the field.
+func emptyTest(field queryField) Predicate {
+	switch field.kind {
+	case queryList:
+		return func(issue *Issue) bool { return len(field.list(issue)) == 0 }
+	case queryDate:
+		return func(issue *Issue) bool { return field.date(issue).IsZero() }
+	case queryNumber:
+		return func(issue *Issue) bool {
+			value, ok := field.number(issue)
+			return !ok || value == 0
+		}
+	}
+	return func(issue *Issue) bool { return field.text(issue) == "" }
+}
+
+// queryParser.compare creates the test of a comparison. A comparison with
+// several values, i.e. "in", is true if one of the values matches.
+func (parser *queryParser) compare(field queryField, op string,
+	values []queryToken, name queryToken) (Predicate, error) {
+	switch field.kind {
+	case queryDate:
+		return parser.compareDate(field, op, values, name)
+	case queryNumber:
+		return parser.compareNumber(field, op, values, name)
+	}
+
+	texts := make([]string, 0)
+	for _, value := range values {
+		texts = append(texts, value.text)
+	}
+
+	var match func(s string) bool
+	negate := false
+	switch op {
+	case "=", "!=":
+		match = func(s string) bool {
+			for _, text := range texts {
+				if strings.EqualFold(s, text) {
+					return true
+				}
+			}
+			return false
+		}
+		negate = op == "!="
+	case "~", "!~":
+		match = func(s string) bool {
+			return strings.Contains(strings.ToLower(s),
+				strings.ToLower(texts[0]))
+		}
+		negate = op == "!~"
+	default:
+		return nil, parser.errorf(name, "operator %q not supported by %q",
+			op, name.text)
+	}
+
+	if field.kind == queryList {
+		return func(issue *Issue) bool {
+			for _, s := range field.list(issue) {
+				if match(s) {
+					return !negate
+				}
+			}
+			return negate
+		}, nil
+	}
+	return func(issue *Issue) bool {
+		return match(field.text(issue)) != negate
+	}, nil
+}
+
+// queryParser.compareDate creates the test of a date comparison. Equality
+// compares the day, issues without date never match.
+func (parser *queryParser) compareDate(field queryField, op string,
+	values []queryToken, name queryToken) (Predicate, error) {
+	dates := make([]time.Time, 0)
+	for _, value := range values {
+		date, err := parser.parseDate(value.text)
+		if err != nil {
+			return nil, parser.errorf(value, "%v", err)
+		}
+		dates = append(dates, date)
+	}
+
+	var match func(date time.Time, value time.Time) bool
+	switch op {
+	case "=":
+		match = sameDay
+	case "!=":
+		match = func(date time.Time, value time.Time) bool {
+			return !sameDay(date, value)
+		}
+	case "<":
+		match = func(date time.Time, value time.Time) bool {
+			return date.Before(value)
+		}
+	case "<=":
+		match = func(date time.Time, value time.Time) bool {
+			return !date.After(value)
+		}
+	case ">":
+		match = func(date time.Time, value time.Time) bool {
+			return date.After(value)
+		}
+	case ">=":
+		match = func(date time.Time, value time.Time) bool {
+			return !date.Before(value)
+		}
+	default:
+		return nil, parser.errorf(name, "operator %q not supported by %q",
+			op, name.text)
+	}
+
+	return func(issue *Issue) bool {
+		date := field.date(issue)
+		if date.IsZero() {
+			return false
+		}
+		for _, value := range dates {
+			if match(date, value) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// sameDay tests if both dates are on the same day.
+func sameDay(a time.Time, b time.Time) bool {
+	return a.Format(dayLayout) == b.In(a.Location()).Format(dayLayout)
+}
+
+// queryParser.parseDate parses an absolute date in the config date format,
+// "now" or a date relative to now, e.g. "-30d" or "-2w".
+func (parser *queryParser) parseDate(value string) (time.Time, error) {
+	if strings.EqualFold(value, "now") {
+		return parser.now, nil
+	}
+
+	date, err := time.ParseInLocation(parser.config.Formats.Date, value,
+		parser.now.Location())
+	if err == nil {
+		return date, nil
+	}
+
+	sign := 1
+	length := value
+	if strings.HasPrefix(length, "-") || strings.HasPrefix(length, "+") {
+		if length[0] == '-' {
+			sign = -1
+		}
+		length = length[1:]
+	}
+	n, unit, err := parseWindowLength(length)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return addUnits(parser.now, unit, sign*n), nil
+}
+
+// queryParser.compareNumber creates the test of a number comparison.
+func (parser *queryParser) compareNumber(field queryField, op string,
+	values []queryToken, name queryToken) (Predicate, error) {
+	numbers := make([]float64, 0)
+	for _, value := range values {
+		number, err := strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return nil, parser.errorf(value, "invalid number %q", value.text)
+		}
+		numbers = append(numbers, number)
+	}
+
+	var match func(number float64, value float64) bool
+	switch op {
+	case "=":
+		match = func(number float64, value float64) bool {
+			return math.Abs(number-value) < 0.001
+		}
+	case "!=":
+		match = func(number float64, value float64) bool {
+			return math.Abs(number-value) >= 0.001
+		}
+	case "<":
+		match = func(number float64, value float64) bool {
+			return number < value
+		}
+	case "<=":
+		match = func(number float64, value float64) bool {
+			return number <= value
+		}
+	case ">":
+		match = func(number float64, value float64) bool {
+			return number > value
+		}
+	case ">=":
+		match = func(number float64, value float64) bool {
+			return number >= value
+		}
+	default:
+		return nil, parser.errorf(name, "operator %q not supported by %q",
+			op, name.text)
+	}
+
+	return func(issue *Issue) bool {
+		number, ok := field.number(issue)
+		if !ok {
+			return false
+		}
+		for _, value := range numbers {
+			if match(number, value) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// Report sections which can be filtered using config.Filters.
+const (
+	SectionWarnings       = "warnings"
+	SectionOldBugs        = "oldBugs"
+	SectionBugs           = "bugs"
+	SectionFeatures       = "features"
+	SectionImprovements   = "improvements"
+	SectionOther          = "other"
+	SectionResources      = "resources"
+	SectionFlow           = "flow"
+	SectionPeople         = "people"
+	SectionStatuses       = "statuses"
+	SectionCumulativeFlow = "cumulativeFlow"
+)
+
+// sections are all report sections which can be filtered.
+var sections = []string{SectionWarnings, SectionOldBugs, SectionBugs,
+	SectionFeatures, SectionImprovements, SectionOther, SectionResources,
+	SectionFlow, SectionPeople, SectionStatuses, SectionCumulativeFlow}
+
+// Config.SectionFilters compiles the filters of the report sections, see
+// config.Filters.
+func (config Config) SectionFilters(now time.Time) (
+	map[string]Predicate, error) {
+	filters := make(map[string]Predicate)
+
+	names := make([]string, 0)
+	for name := range config.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !contains(sections, name) {
+			return nil, fmt.Errorf("unknown report section %q, valid "+
+				"sections are %s", name, strings.Join(sections, ", "))
+		}
+		test, err := ParseQuery(config.Filters[name], now, config)
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %v", name, err)
+		}
+		filters[name] = test
+	}
+
+	return filters, nil
+}
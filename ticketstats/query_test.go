@@ -0,0 +1,163 @@
+package ticketstats
+
+import (
+	"log"
+	"testing"
+)
+
+func queryTestIssues() []*Issue {
+	bug := NewIssue()
+	bug.Key = "PRJ-1"
+	bug.Type = "Bug"
+	bug.Status = "In Progress"
+	bug.Priority = "Blocker"
+	bug.Summary = "Crash on startup"
+	bug.Labels = append(bug.Labels, "customer")
+	bug.Created = testNow.AddDate(0, 0, -10)
+	bug.OriginalEstimate = 16
+	bug.Fields["Story Points"] = FieldValue{Type: FieldNumber, Number: 5}
+
+	legacy := NewIssue()
+	legacy.Key = "PRJ-2"
+	legacy.Type = "Bug"
+	legacy.Status = "Open"
+	legacy.Priority = "Critical"
+	legacy.Labels = append(legacy.Labels, "legacy")
+	legacy.Created = testNow.AddDate(0, -2, 0)
+
+	feature := NewIssue()
+	feature.Key = "OTHER-1"
+	feature.Type = "New Feature"
+	feature.Status = "Closed"
+	feature.Priority = "Minor"
+	feature.Created = testNow.AddDate(0, 0, -3)
+	feature.Resolved = testNow.AddDate(0, 0, -1)
+
+	return []*Issue{bug, legacy, feature}
+}
+
+func queryKeys(t *testing.T, expr string) string {
+	config := DefaultConfig()
+	config.Customs.Fields["Custom field (Story Points)"] = FieldNumber
+
+	issues, err := FilterByQuery(queryTestIssues(), expr, testNow, config)
+	if err != nil {
+		log.Println("TEST: filter failed", err)
+		t.Fail()
+		return ""
+	}
+	keys := ""
+	for _, issue := range issues {
+		keys += issue.Key + " "
+	}
+	return keys
+}
+
+func TestParseQuery(t *testing.T) {
+	expected := map[string]string{
+		"": "PRJ-1 PRJ-2 OTHER-1 ",
+		"type = Bug AND priority in (Blocker, Critical) AND " +
+			"labels != legacy AND created > -30d": "PRJ-1 ",
+		"type = bug":  "PRJ-1 PRJ-2 ",
+		"type != Bug": "OTHER-1 ",
+		"status = \"In Progress\" OR status = Open":     "PRJ-1 PRJ-2 ",
+		"NOT (type = Bug AND priority = Critical)":      "PRJ-1 OTHER-1 ",
+		"priority not in (Blocker, Minor)":              "PRJ-2 ",
+		"summary ~ crash":                               "PRJ-1 ",
+		"summary !~ crash":                              "PRJ-2 OTHER-1 ",
+		"labels is empty":                               "OTHER-1 ",
+		"labels is not EMPTY":                           "PRJ-1 PRJ-2 ",
+		"resolved is empty":                             "PRJ-1 PRJ-2 ",
+		"resolved >= -1w":                               "OTHER-1 ",
+		"created < 2021-11-01":                          "PRJ-2 ",
+		"created = 2021-11-12":                          "OTHER-1 ",
+		"project = OTHER":                               "OTHER-1 ",
+		"statusCategory = done":                         "OTHER-1 ",
+		"estimate > 8":                                  "PRJ-1 ",
+		"\"Story Points\" >= 5":                         "PRJ-1 ",
+		"type = Bug and created > -1m or key = OTHER-1": "PRJ-1 OTHER-1 ",
+	}
+	for expr, keys := range expected {
+		result := queryKeys(t, expr)
+		if result != keys {
+			log.Println("TEST: wrong result of", expr+":", result)
+			t.Fail()
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	invalid := []string{
+		"type",
+		"type =",
+		"unknown = 1",
+		"type < Bug",
+		"created > yesterday",
+		"estimate = many",
+		"type = Bug AND",
+		"(type = Bug",
+		"type = \"Bug",
+		"type in Bug",
+		"type in (Bug,",
+		"labels is full",
+		"type => Bug",
+		"type = Bug )",
+	}
+	for _, expr := range invalid {
+		_, err := ParseQuery(expr, testNow, DefaultConfig())
+		if err == nil {
+			log.Println("TEST: invalid filter accepted", expr)
+			t.Fail()
+		}
+	}
+}
+
+func TestSectionFilters(t *testing.T) {
+	config := DefaultConfig()
+	config.Filters[SectionBugs] = "labels != legacy"
+
+	report, err := GenerateReport(queryTestIssues(), "", config,
+		Options{AsOf: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Bugs.Count != 1 {
+		log.Println("TEST: bug section not filtered", report.Bugs.Count)
+		t.Fail()
+	}
+
+	config.Filters["unknown"] = "type = Bug"
+	if _, err := config.SectionFilters(testNow); err == nil {
+		log.Println("TEST: unknown section accepted")
+		t.Fail()
+	}
+	delete(config.Filters, "unknown")
+	config.Filters[SectionPeople] = "type ="
+	if _, err := config.SectionFilters(testNow); err == nil {
+		log.Println("TEST: invalid section filter accepted")
+		t.Fail()
+	}
+	_, err = GenerateReport(queryTestIssues(), "", config,
+		Options{AsOf: testNow})
+	if err == nil {
+		log.Println("TEST: report with invalid section filter")
+		t.Fail()
+	}
+}
+
+func TestLoadIssuesFilter(t *testing.T) {
+	source := testSource(queryTestIssues())
+
+	issues, err := LoadIssues(source, DefaultConfig(),
+		Options{AsOf: testNow, Filter: "type = Bug"})
+	if err != nil || len(issues) != 2 {
+		log.Println("TEST: wrong filtered issues", len(issues), err)
+		t.Fail()
+	}
+
+	_, err = LoadIssues(source, DefaultConfig(), Options{Filter: "type"})
+	if err == nil {
+		log.Println("TEST: invalid filter accepted")
+		t.Fail()
+	}
+}
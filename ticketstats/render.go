@@ -3,17 +3,30 @@ package ticketstats
 import (
 	"bufio"
 	_ "embed"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
 	"time"
 )
 
 //go:embed report.tmpl
 var reportTemplate string
 
+//go:embed report.css
+var reportStylesheet string
+
 // loadTemplate loads the template.
-func loadTemplate(config Config) *template.Template {
+// Besides "second", the template functions "css" (embedded stylesheet)
+// and "stylesheet" (URL of an external stylesheet, config.Stylesheet) are
+// provided for styling the report. The SVG charts are provided by the
+// functions "bugChart", "usageChart", "featureChart" and
+// "cumulativeFlowChart".
+func loadTemplate(config Config) (*template.Template, error) {
 	var err error
 
 	t := template.New("report")
@@ -25,33 +38,55 @@ func loadTemplate(config Config) *template.Template {
 		return sec
 	}})
 
+	t.Funcs(template.FuncMap{
+		"css": func() template.CSS {
+			return template.CSS(reportStylesheet)
+		},
+		"stylesheet": func() string {
+			return config.Stylesheet
+		},
+		"bugChart":            BugChart,
+		"usageChart":          UsageChart,
+		"featureChart":        FeatureChart,
+		"cumulativeFlowChart": CumulativeFlowChart,
+	})
+
 	if config.Template != "" {
 		t, err = t.ParseFiles(config.Template)
-	}
-
-	if config.Template == "" || err != nil {
+	} else {
 		t, err = t.Parse(reportTemplate)
-		if err != nil {
-			panic(err)
-		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("template: %v", err)
 	}
 
-	return t
+	return t, nil
 }
 
 // Report groups all data needed to render the HTML report.
+// The json tags define the schema of the JSON report. Values which are
+// pre-formatted for the HTML report are not part of the JSON report, it
+// contains the raw values instead (dates as RFC 3339, work in hours).
 type Report struct {
-	Component    string
-	Date         string
-	OldBugs      []ReportIssue
-	Bugs         ReportBugs
-	Features     []ReportIssue
-	Improvements []ReportIssue
-	OtherCount   int
-	Other        OtherReport
-	Resources    ResourceReport
-	HasWarnings  bool
-	Warnings     Warnings
+	Component    string         `json:"component"`
+	Date         string         `json:"-"`
+	AsOf         time.Time      `json:"asOf"`
+	OldBugs      []ReportIssue  `json:"oldBugs"`
+	Bugs         ReportBugs     `json:"bugs"`
+	Features     []ReportIssue  `json:"features"`
+	Improvements []ReportIssue  `json:"improvements"`
+	OtherCount   int            `json:"-"`
+	Other        OtherReport    `json:"other"`
+	Resources    ResourceReport `json:"resources"`
+	HasStatuses  bool           `json:"-"`
+	Statuses     ReportStatuses `json:"statuses"`
+	// daily ticket counts per status category
+	HasCumulativeFlow bool                 `json:"-"`
+	CumulativeFlow    ReportCumulativeFlow `json:"cumulativeFlow"`
+	HasWarnings       bool                 `json:"-"`
+	Warnings          Warnings             `json:"warnings"`
+	HasHistory        bool                 `json:"-"`
+	History           ReportHistory        `json:"history"`
 }
 
 // NewReport initializes a new Report.
@@ -64,41 +99,76 @@ func NewReport() Report {
 	report.Improvements = make([]ReportIssue, 0)
 	report.Other = NewOtherReport()
 	report.Resources = NewResourceReport()
+	report.HasStatuses = false
+	report.Statuses = NewReportStatuses()
+	report.HasCumulativeFlow = false
+	report.CumulativeFlow = NewReportCumulativeFlow()
 	report.HasWarnings = false
 	report.Warnings = NewWarnings()
+	report.HasHistory = false
+	report.History = NewReportHistory()
 
 	return report
 }
 
 // Warnings groups all sanitize warnings.
 type Warnings struct {
-	Count          int
-	NoActivity     []ReportIssue
-	InvalidBooking []InvalidBooking
+	Count          int              `json:"count"`
+	NoActivity     []ReportIssue    `json:"noActivity"`
+	InvalidBooking []InvalidBooking `json:"invalidBookings"`
+	// work logs rejected by the parser
+	RejectedWorkLogs []RejectedWorkLog `json:"rejectedWorkLogs"`
+	// dates rejected by the parser
+	InvalidDates []InvalidDate `json:"invalidDates"`
 }
 
 // SanitizeResult.ToWarnings converts a SanitizeResult to a Warnings object.
 // Warnings is a "rendered" sanitize result.
-func (sr SanitizeResult) ToWarnings(jiraBaseUrl string,
-	config Config) Warnings {
+func (sr SanitizeResult) ToWarnings(jiraBaseUrl string, now time.Time,
+	config Config, calendar *Calendar) Warnings {
 	warnings := NewWarnings()
-	warnings.Count = len(sr.NoActivity) + len(sr.InvalidWorkLogs)
+	warnings.Count = len(sr.NoActivity) + len(sr.InvalidWorkLogs) +
+		len(sr.RejectedWorkLogs) + len(sr.InvalidDates)
 	for _, na := range sr.NoActivity {
 		warnings.NoActivity = append(warnings.NoActivity,
-			na.ToReportIssue(jiraBaseUrl, config))
+			na.ToReportIssue(jiraBaseUrl, now, config, calendar))
 	}
 	for _, il := range sr.InvalidWorkLogs {
 		ib := NewInvalidBooking()
-		ib.Issue = il.Issue.ToReportIssue(jiraBaseUrl, config)
+		ib.Issue = il.Issue.ToReportIssue(jiraBaseUrl, now, config, calendar)
 		for _, wl := range il.Logs {
 			ib.Logs = append(ib.Logs, InvalidLog{
-				Activity: wl.Activity,
-				Date:     wl.Date.Format(config.Formats.Date),
-				Effort:   formatWork(wl.Hours),
+				Activity:  wl.Activity,
+				Date:      wl.Date.Format(config.Formats.Date),
+				DateValue: wl.Date,
+				Effort:    calendar.FormatWork(wl.Hours),
+				Hours:     wl.Hours,
 			})
 		}
 		warnings.InvalidBooking = append(warnings.InvalidBooking, ib)
 	}
+	for _, err := range sr.RejectedWorkLogs {
+		rl := RejectedWorkLog{
+			Key:    err.Key,
+			Value:  err.Value,
+			Reason: err.Reason,
+		}
+		if jiraBaseUrl != "" {
+			rl.JiraUrl = jiraBaseUrl + err.Key
+		}
+		warnings.RejectedWorkLogs = append(warnings.RejectedWorkLogs, rl)
+	}
+	for _, err := range sr.InvalidDates {
+		id := InvalidDate{
+			Key:    err.Key,
+			Column: err.Column,
+			Value:  err.Value,
+		}
+		if jiraBaseUrl != "" {
+			id.JiraUrl = jiraBaseUrl + err.Key
+		}
+		warnings.InvalidDates = append(warnings.InvalidDates, id)
+	}
 	return warnings
 }
 
@@ -108,14 +178,32 @@ func NewWarnings() Warnings {
 
 	warnings.NoActivity = make([]ReportIssue, 0)
 	warnings.InvalidBooking = make([]InvalidBooking, 0)
+	warnings.RejectedWorkLogs = make([]RejectedWorkLog, 0)
+	warnings.InvalidDates = make([]InvalidDate, 0)
 
 	return warnings
 }
 
+// RejectedWorkLog represents a work log which couldn't be parsed.
+type RejectedWorkLog struct {
+	JiraUrl string `json:"jiraUrl,omitempty"`
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+	Reason  string `json:"reason"`
+}
+
+// InvalidDate represents a date which couldn't be parsed.
+type InvalidDate struct {
+	JiraUrl string `json:"jiraUrl,omitempty"`
+	Key     string `json:"key"`
+	Column  string `json:"column"`
+	Value   string `json:"value"`
+}
+
 // InvalidBooking represents an invalid time recording.
 type InvalidBooking struct {
-	Issue ReportIssue
-	Logs  []InvalidLog
+	Issue ReportIssue  `json:"issue"`
+	Logs  []InvalidLog `json:"logs"`
 }
 
 // NewInvalidBooking initializes a new Warnings InvalidBooking.
@@ -129,16 +217,20 @@ func NewInvalidBooking() InvalidBooking {
 
 // InvalidLog groups the data of an (invalid) time log.
 type InvalidLog struct {
-	Activity string
-	Date     string
-	Effort   string
+	Activity  string    `json:"activity"`
+	Date      string    `json:"-"`
+	DateValue time.Time `json:"date"`
+	Effort    string    `json:"-"`
+	Hours     Work      `json:"hours"`
 }
 
 // ResourceReport groups the data on spend working hours.
 type ResourceReport struct {
-	Spend   []ResourceSpend
-	Usage   [][]ResourceGroup
-	Average []ResourceAverage
+	Spend   []ResourceSpend   `json:"spend"`
+	Usage   [][]ResourceGroup `json:"usage"`
+	Average []ResourceAverage `json:"average"`
+	Flow    [][]ResourceFlow  `json:"flow"`
+	People  []ResourcePerson  `json:"people"`
 }
 
 // NewResourceReport initializes a new ResourceReport.
@@ -148,6 +240,8 @@ func NewResourceReport() ResourceReport {
 	report.Spend = make([]ResourceSpend, 0)
 	report.Usage = make([][]ResourceGroup, 0)
 	report.Average = make([]ResourceAverage, 0)
+	report.Flow = make([][]ResourceFlow, 0)
+	report.People = make([]ResourcePerson, 0)
 
 	return report
 }
@@ -155,8 +249,8 @@ func NewResourceReport() ResourceReport {
 // ResourceAverage groups the information how much time was
 // spend on a ticket type in average.
 type ResourceAverage struct {
-	TimeRange string
-	Details   []ResourceAverageDetails
+	TimeRange string                   `json:"timeRange"`
+	Details   []ResourceAverageDetails `json:"details"`
 }
 
 // NewResourceAverage initializes a new ResourceAverage object.
@@ -170,15 +264,19 @@ func NewResourceAverage() ResourceAverage {
 
 // ResourceSpend groups the effort spend on a time range.
 type ResourceSpend struct {
-	TimeRange string
-	Effort    string
-	FTE       string
+	TimeRange string  `json:"timeRange"`
+	Effort    string  `json:"-"`
+	Hours     Work    `json:"hours"`
+	FTE       string  `json:"-"`
+	FTEValue  float64 `json:"fte"`
 }
 
-// ResourceGroup groups the ResourceDetails with a type name.
+// ResourceGroup groups the ResourceDetails of a time range with a type
+// name, e.g. "Type" or "Label".
 type ResourceGroup struct {
-	Type    string
-	Details []ResourceDetails
+	Type      string            `json:"type"`
+	TimeRange string            `json:"timeRange"`
+	Details   []ResourceDetails `json:"details"`
 }
 
 // NewResourceGroup initializes a new ResourceGroup.
@@ -192,61 +290,266 @@ func NewResourceGroup() ResourceGroup {
 
 // ResourceDetails groups effort spend a type.
 type ResourceDetails struct {
-	Type    string
-	Work    string
-	FTE     string
-	Percent int
+	Type     string  `json:"name"`
+	Work     string  `json:"-"`
+	Hours    Work    `json:"hours"`
+	FTE      string  `json:"-"`
+	FTEValue float64 `json:"fte"`
+	Percent  int     `json:"percent"`
+}
+
+// ResourcePerson groups the effort booked by a person.
+type ResourcePerson struct {
+	Name   string                `json:"name"`
+	Ranges []ResourcePersonRange `json:"ranges"`
+	// days with more than config.Efforts.DailyMaximum booked hours
+	Overbookings []ResourceOverbooking `json:"overbookings"`
+	Overbooked   bool                  `json:"overbooked"`
+}
+
+// NewResourcePerson initializes a new ResourcePerson.
+func NewResourcePerson() ResourcePerson {
+	var person ResourcePerson
+
+	person.Ranges = make([]ResourcePersonRange, 0)
+	person.Overbookings = make([]ResourceOverbooking, 0)
+
+	return person
+}
+
+// ResourcePersonRange groups the effort booked by a person in a time range,
+// by activity and by ticket type.
+type ResourcePersonRange struct {
+	TimeRange  string            `json:"timeRange"`
+	Effort     string            `json:"-"`
+	Hours      Work              `json:"hours"`
+	FTE        string            `json:"-"`
+	FTEValue   float64           `json:"fte"`
+	Activities []ResourceDetails `json:"activities"`
+	Types      []ResourceDetails `json:"types"`
+}
+
+// ResourceOverbooking groups the effort booked by a person on a day, if it
+// exceeds the daily maximum.
+type ResourceOverbooking struct {
+	Date      string    `json:"-"`
+	DateValue time.Time `json:"date"`
+	Effort    string    `json:"-"`
+	Hours     Work      `json:"hours"`
 }
 
 // ResourceAverageDetails groups the data on average resource
 // usage for a type.
 type ResourceAverageDetails struct {
-	Type   string
-	Median string
-	Mean   string
-	Count  int
+	Type        string `json:"type"`
+	Median      string `json:"-"`
+	MedianHours Work   `json:"medianHours"`
+	Mean        string `json:"-"`
+	MeanHours   Work   `json:"meanHours"`
+	Count       int    `json:"count"`
+}
+
+// ResourceFlow groups the lead time statistics of a time range for the
+// values of a grouping, e.g. the ticket types.
+type ResourceFlow struct {
+	TimeRange string                `json:"timeRange"`
+	Group     string                `json:"group"`
+	Details   []ResourceFlowDetails `json:"details"`
+}
+
+// NewResourceFlow initializes a new ResourceFlow object.
+func NewResourceFlow() ResourceFlow {
+	var flow ResourceFlow
+
+	flow.Details = make([]ResourceFlowDetails, 0)
+
+	return flow
+}
+
+// ResourceFlowDetails groups the lead time statistics for a group value.
+type ResourceFlowDetails struct {
+	Name       string  `json:"name"`
+	Count      int     `json:"count"`
+	Mean       string  `json:"-"`
+	MeanDays   float64 `json:"meanDays"`
+	Median     string  `json:"-"`
+	MedianDays float64 `json:"medianDays"`
+	P85        string  `json:"-"`
+	P85Days    float64 `json:"p85Days"`
+	P95        string  `json:"-"`
+	P95Days    float64 `json:"p95Days"`
+}
+
+// ReportStatuses groups the time in status statistics of the tickets with
+// changelog.
+type ReportStatuses struct {
+	Types []ReportStatusType `json:"types"`
+	// tickets reopened after they were done
+	Reopened []ReportIssue `json:"reopened"`
+}
+
+// NewReportStatuses initializes a new ReportStatuses object.
+func NewReportStatuses() ReportStatuses {
+	var statuses ReportStatuses
+
+	statuses.Types = make([]ReportStatusType, 0)
+	statuses.Reopened = make([]ReportIssue, 0)
+
+	return statuses
+}
+
+// ReportStatusType groups the time in status statistics of a ticket type.
+type ReportStatusType struct {
+	Type string `json:"type"`
+	// number of tickets with changelog
+	Count int `json:"count"`
+	// mean flow efficiency, i.e. the share of active time
+	FlowEfficiency    int                `json:"flowEfficiencyPercent"`
+	HasFlowEfficiency bool               `json:"-"`
+	Statuses          []ReportStatusTime `json:"statuses"`
+}
+
+// NewReportStatusType initializes a new ReportStatusType object.
+func NewReportStatusType() ReportStatusType {
+	var statusType ReportStatusType
+
+	statusType.Statuses = make([]ReportStatusTime, 0)
+
+	return statusType
+}
+
+// ReportStatusTime groups the time in status statistics of a status.
+type ReportStatusTime struct {
+	Status     string  `json:"status"`
+	Category   string  `json:"category"`
+	Count      int     `json:"count"`
+	Mean       string  `json:"-"`
+	MeanDays   float64 `json:"meanDays"`
+	Median     string  `json:"-"`
+	MedianDays float64 `json:"medianDays"`
+}
+
+// ReportCumulativeFlow groups the cumulative flow series of the ticket
+// types. All series have one value for each date.
+type ReportCumulativeFlow struct {
+	Dates []string           `json:"-"`
+	Times []time.Time        `json:"dates"`
+	Types []ReportFlowSeries `json:"types"`
+}
+
+// NewReportCumulativeFlow initializes a new ReportCumulativeFlow object.
+func NewReportCumulativeFlow() ReportCumulativeFlow {
+	var flow ReportCumulativeFlow
+
+	flow.Dates = make([]string, 0)
+	flow.Times = make([]time.Time, 0)
+	flow.Types = make([]ReportFlowSeries, 0)
+
+	return flow
+}
+
+// ReportFlowSeries groups the daily ticket counts of a ticket type.
+type ReportFlowSeries struct {
+	Type       string               `json:"type"`
+	Categories []ReportFlowCategory `json:"categories"`
+}
+
+// ReportFlowCategory is the daily ticket count of a status category.
+type ReportFlowCategory struct {
+	Category string `json:"category"`
+	Counts   []int  `json:"counts"`
+}
+
+// ReportCumulativeFlow.WriteCSV writes the cumulative flow series as CSV
+// to w. Each row contains the date, the ticket type and the counts of the
+// status categories.
+func (flow ReportCumulativeFlow) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+
+	err := cw.Write(append([]string{"Date", "Type"}, categories...))
+	if err != nil {
+		return err
+	}
+	for _, series := range flow.Types {
+		for i, date := range flow.Dates {
+			row := []string{date, series.Type}
+			for _, category := range series.Categories {
+				row = append(row, strconv.Itoa(category.Counts[i]))
+			}
+			err = cw.Write(row)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatDays converts a duration in days to a string.
+func formatDays(days float64) string {
+	return fmt.Sprintf("%.1fd", days)
 }
 
 // Link groups the data for web link.
 type Link struct {
-	Name string
-	Url  string
+	Name string `json:"name"`
+	Url  string `json:"url"`
 }
 
 // ReportIssue groups all data about a Jira issue needed for
 // rendering the report.
 type ReportIssue struct {
-	JiraUrl     string
-	Key         string
-	Summary     string
-	Activity    string
-	Priority    string
-	HasDue      bool
-	Due         string
-	Created     string
-	Age         int
-	Labels      []string
-	Creator     string
-	Assignee    string
-	Status      string
-	FixVersions []string
-	Estimate    string
-	HasEstimate bool
-	TimeSpend   string
-	HasTime     bool
-	Progress    int
-	AtRisk      bool
-	FTE         string
-	HasChilds   bool
-	Overtime    bool
-	Childs      []ReportIssue
-	Parents     []Link
+	JiraUrl  string     `json:"jiraUrl,omitempty"`
+	Key      string     `json:"key"`
+	Summary  string     `json:"summary"`
+	Activity string     `json:"activity,omitempty"`
+	Priority string     `json:"priority"`
+	HasDue   bool       `json:"-"`
+	Due      string     `json:"-"`
+	DueDate  *time.Time `json:"due,omitempty"`
+	Created  string     `json:"-"`
+	// creation date, nil if unknown
+	CreatedDate *time.Time `json:"created,omitempty"`
+	Age         int        `json:"ageDays"`
+	// age in working days of the calendar, see config.Calendar
+	BusinessAge int      `json:"businessAgeDays"`
+	Labels      []string `json:"labels"`
+	Creator     string   `json:"creator"`
+	Assignee    string   `json:"assignee"`
+	Status      string   `json:"status"`
+	// status category, see config.States.Categories
+	StatusCategory string        `json:"statusCategory"`
+	FixVersions    []string      `json:"fixVersions"`
+	Estimate       string        `json:"-"`
+	EstimateHours  Work          `json:"estimateHours"`
+	HasEstimate    bool          `json:"-"`
+	TimeSpend      string        `json:"-"`
+	TimeSpendHours Work          `json:"timeSpendHours"`
+	HasTime        bool          `json:"-"`
+	Progress       int           `json:"progressPercent"`
+	AtRisk         bool          `json:"atRisk"`
+	FTE            string        `json:"-"`
+	FTEValue       float64       `json:"fte"`
+	HasChilds      bool          `json:"-"`
+	Overtime       bool          `json:"overtime"`
+	Childs         []ReportIssue `json:"childs,omitempty"`
+	Parents        []Link        `json:"parents,omitempty"`
+	// generic custom field values by name, see config.Customs.Fields
+	Fields map[string]string `json:"fields,omitempty"`
+	// keys of the issues blocking this issue
+	BlockedBy []string `json:"blockedBy,omitempty"`
+	// number of reopens, only known with changelog
+	Reopens int `json:"reopens,omitempty"`
 }
 
 // Issue.ToReportIssue converts an Issue to a ReportIssue, i.e. this
-// function renders the issue.
-func (issue *Issue) ToReportIssue(jiraBaseUrl string,
-	config Config) ReportIssue {
+// function renders the issue. Ages and FTEs are calculated relative to now,
+// using the working calendar.
+func (issue *Issue) ToReportIssue(jiraBaseUrl string, now time.Time,
+	config Config, calendar *Calendar) ReportIssue {
 	var rissue ReportIssue
 	var noDate time.Time
 
@@ -262,28 +565,45 @@ func (issue *Issue) ToReportIssue(jiraBaseUrl string,
 	} else {
 		rissue.HasDue = true
 		rissue.Due = issue.Due.Format(config.Formats.Date)
+		due := issue.Due
+		rissue.DueDate = &due
 		if issue.OriginalEstimate > 0.1 {
 			fte := covertToFTE(issue.Due,
-				issue.OriginalEstimate-issue.TimeSpend)
+				issue.OriginalEstimate-issue.TimeSpend, now, calendar)
 			rissue.FTE = fmt.Sprintf("%.2f", fte)
+			rissue.FTEValue = fte
 			rissue.HasEstimate = true
 			rissue.AtRisk = fte > 1.0
 		}
 	}
 	if issue.Created != noDate {
 		rissue.Created = issue.Created.Format(config.Formats.Date)
-		rissue.Age = convertToAge(issue.Created)
+		created := issue.Created
+		rissue.CreatedDate = &created
+		rissue.Age = convertToAge(issue.Created, now)
+		rissue.BusinessAge = int(calendar.WorkingDays(issue.Created, now))
 	}
 	rissue.Labels = issue.Labels
 	rissue.Creator = issue.Creator
 	rissue.Assignee = issue.Assignee
 	rissue.Status = issue.Status
+	rissue.StatusCategory = config.StatusCategory(issue)
 	rissue.FixVersions = issue.FixVersions
+	rissue.BlockedBy = issue.LinkedKeys(LinkTypeBlocks, LinkInward)
+	rissue.Reopens = issue.Reopens(now, config)
+	if len(issue.Fields) > 0 {
+		rissue.Fields = make(map[string]string)
+		for name, value := range issue.Fields {
+			rissue.Fields[name] = value.ToString(config)
+		}
+	}
+	rissue.EstimateHours = issue.OriginalEstimate
 	if issue.OriginalEstimate > 0.001 {
-		rissue.Estimate = formatWork(issue.OriginalEstimate)
+		rissue.Estimate = calendar.FormatWork(issue.OriginalEstimate)
 	}
+	rissue.TimeSpendHours = issue.TimeSpend
 	if issue.TimeSpend > 0.1 {
-		rissue.TimeSpend = formatWork(issue.TimeSpend)
+		rissue.TimeSpend = calendar.FormatWork(issue.TimeSpend)
 	}
 	if issue.OriginalEstimate > 0.1 && issue.TimeSpend > 0.1 {
 		rissue.HasTime = true
@@ -297,7 +617,8 @@ func (issue *Issue) ToReportIssue(jiraBaseUrl string,
 			Url:  jiraBaseUrl + issue.Key,
 			Name: issue.Key,
 		}
-		rissue.Childs = flattenTree(issue, parent, jiraBaseUrl, config)
+		rissue.Childs = flattenTree(issue, parent, jiraBaseUrl, now, config,
+			calendar)
 		rissue.HasChilds = (len(rissue.Childs) > 0)
 	}
 
@@ -305,18 +626,18 @@ func (issue *Issue) ToReportIssue(jiraBaseUrl string,
 }
 
 // flattenTree flattens the child tree of the given issue to a list.
-func flattenTree(issue *Issue, parent Link,
-	jiraBaseUrl string, config Config) []ReportIssue {
+func flattenTree(issue *Issue, parent Link, jiraBaseUrl string,
+	now time.Time, config Config, calendar *Calendar) []ReportIssue {
 	childs := make([]ReportIssue, 0)
 
 	for _, child := range issue.Childs {
-		rissue := child.ToReportIssue(jiraBaseUrl, config)
+		rissue := child.ToReportIssue(jiraBaseUrl, now, config, calendar)
 		rissue.Parents = append(rissue.Parents, parent)
-		if rissue.Status != config.States.Closed {
+		if rissue.StatusCategory != CategoryDone {
 			childs = append(childs, rissue)
 		}
 		for _, rc := range rissue.Childs {
-			if rc.Status != config.States.Closed {
+			if rc.StatusCategory != CategoryDone {
 				childs = append(childs, rc)
 			}
 		}
@@ -325,19 +646,23 @@ func flattenTree(issue *Issue, parent Link,
 	return childs
 }
 
-// covertToFTE calculates the needed FTEs based on the remaining days.
-func covertToFTE(due time.Time, remainingEffort Work) float64 {
-	neededDays := float64(remainingEffort) / 8.0
-	remainingTime := time.Until(due)
-	remainingWeeks := (remainingTime.Hours() / 24.0) / 7.0
-	remainingDays := remainingWeeks * 5
-	fte := neededDays / float64(remainingDays)
+// covertToFTE calculates the needed FTEs based on the remaining working
+// days of the calendar.
+func covertToFTE(due time.Time, remainingEffort Work, now time.Time,
+	calendar *Calendar) float64 {
+	neededDays := float64(remainingEffort / calendar.HoursPerDay)
+	remainingDays := calendar.WorkingDays(now, due)
+	if remainingDays == 0 {
+		// no working day left, e.g. due on a weekend
+		remainingDays = 1
+	}
+	fte := neededDays / remainingDays
 	return fte
 }
 
 // convertToAge calculates the age of days of an issue.
-func convertToAge(date time.Time) int {
-	duration := time.Since(date)
+func convertToAge(date time.Time, now time.Time) int {
+	duration := now.Sub(date)
 	return int(duration.Hours()) / 24
 }
 
@@ -359,26 +684,33 @@ func NewReportIssue() ReportIssue {
 
 // ReportBugs groups the data for the bug report section.
 type ReportBugs struct {
-	Count     int
-	Week      ReportCount
-	Month     ReportCount
-	BugStats  []ReportBugStats
-	BugCounts BugCounts
+	Count int `json:"count"`
+	// created and resolved bugs of the time windows
+	Ranges []ReportRangeCount `json:"ranges"`
+	// created and resolved bugs of the last week and month, kept for custom
+	// templates written before the configurable time windows
+	Week      ReportCount      `json:"-"`
+	Month     ReportCount      `json:"-"`
+	BugStats  []ReportBugStats `json:"bugStats"`
+	BugCounts BugCounts        `json:"bugCounts"`
 }
 
 // NewReportBugs initializes a new ReportBugs object.
 func NewReportBugs() ReportBugs {
 	var report ReportBugs
 
+	report.Ranges = make([]ReportRangeCount, 0)
 	report.BugStats = make([]ReportBugStats, 0)
+	report.BugCounts = NewBugCounts()
 
 	return report
 }
 
 // BugCounts groups the bug numbers by version
 type BugCounts struct {
-	Versions []string
-	Values   [][]string
+	Versions []string      `json:"versions"`
+	Values   [][]string    `json:"-"`
+	Levels   []BugCountRow `json:"securityLevels"`
 }
 
 // BugCounts initializes a new BugCounts object.
@@ -386,22 +718,37 @@ func NewBugCounts() BugCounts {
 	var bugCounts BugCounts
 	bugCounts.Versions = make([]string, 0)
 	bugCounts.Values = make([][]string, 0)
+	bugCounts.Levels = make([]BugCountRow, 0)
 	return bugCounts
 }
 
+// BugCountRow groups the bug numbers of a security level. The counts are
+// ordered like the versions.
+type BugCountRow struct {
+	Security string `json:"securityLevel"`
+	Counts   []int  `json:"counts"`
+	Sum      int    `json:"sum,omitempty"`
+}
+
 // ReportCount groups the count changes for a issues type.
 type ReportCount struct {
-	Created  int
-	Resolved int
-	Diff     int
+	Created  int `json:"created"`
+	Resolved int `json:"resolved"`
+	Diff     int `json:"diff"`
+}
+
+// ReportRangeCount groups the count changes of a time window.
+type ReportRangeCount struct {
+	TimeRange string `json:"timeRange"`
+	ReportCount
 }
 
 // ReportBugStats groups the bug statistics for a fix version.
 type ReportBugStats struct {
-	Version  string
-	Security string
-	Count    int
-	Bugs     []ReportIssue
+	Version  string        `json:"version"`
+	Security string        `json:"securityLevel"`
+	Count    int           `json:"count"`
+	Bugs     []ReportIssue `json:"bugs"`
 }
 
 // NewReportBugStats initializes a new ReportBugStats object.
@@ -415,45 +762,145 @@ func NewReportBugStats() ReportBugStats {
 
 // OtherReport groups the data for the "other issues" section.
 type OtherReport struct {
-	Count int
-	Week  []OtherTypeStats
-	Month []OtherTypeStats
+	Count  int          `json:"count"`
+	Ranges []OtherRange `json:"ranges"`
+	// type statistics of the last week and month, kept for custom templates
+	// written before the configurable time windows
+	Week  []OtherTypeStats `json:"-"`
+	Month []OtherTypeStats `json:"-"`
 }
 
 // NewOtherReport initializes a new OtherReport object.
 func NewOtherReport() OtherReport {
 	var report OtherReport
 
+	report.Ranges = make([]OtherRange, 0)
 	report.Week = make([]OtherTypeStats, 0)
 	report.Month = make([]OtherTypeStats, 0)
 
 	return report
 }
 
+// OtherRange groups the "other issue" type statistics of a time window.
+type OtherRange struct {
+	TimeRange string           `json:"timeRange"`
+	Types     []OtherTypeStats `json:"types"`
+}
+
 // OtherTypeStats groups the data for "other issue" types.
 type OtherTypeStats struct {
-	Type   string
-	Count  int
-	Report ReportCount
+	Type   string      `json:"type"`
+	Count  int         `json:"count"`
+	Report ReportCount `json:"report"`
 }
 
-// Report.Render renders an HTMl report.
-func (report Report) Render(config Config) {
-	path := "./report_" + report.Component + ".html"
+// ReportHistory groups the data of the history section, i.e. the
+// development over the last program runs.
+type ReportHistory struct {
+	Dates     []string             `json:"-"`
+	Times     []time.Time          `json:"dates"`
+	OpenBugs  [][]string           `json:"-"`
+	BugLevels []BugCountRow        `json:"openBugs"`
+	Bugs      []ReportHistoryCount `json:"bugs"`
+}
 
-	_ = os.Remove(path)
+// NewReportHistory initializes a new ReportHistory object.
+func NewReportHistory() ReportHistory {
+	var history ReportHistory
+
+	history.Dates = make([]string, 0)
+	history.Times = make([]time.Time, 0)
+	history.OpenBugs = make([][]string, 0)
+	history.BugLevels = make([]BugCountRow, 0)
+	history.Bugs = make([]ReportHistoryCount, 0)
+
+	return history
+}
+
+// ReportHistoryCount groups the bug numbers of one program run.
+type ReportHistoryCount struct {
+	Date  string    `json:"-"`
+	Time  time.Time `json:"date"`
+	Count int       `json:"count"`
+	// changes of the first time window, the last week by default
+	Range ReportRangeCount `json:"range"`
+	// changes of the first time window, kept for custom templates written
+	// before the configurable time windows
+	Week ReportCount `json:"-"`
+}
+
+// reportPath returns the path of the report file for the given format in
+// the directory dir.
+func (report Report) reportPath(dir string, format string) string {
+	return filepath.Join(dir, "report_"+report.Component+"."+format)
+}
+
+// writeFile creates the file at path and writes its content using write.
+func writeFile(path string, write func(w io.Writer) error) error {
 	f, err := os.Create(path)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	defer f.Close()
+
 	w := bufio.NewWriter(f)
+	err = write(w)
+	if err != nil {
+		return err
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
 
-	t := loadTemplate(config)
-	err = t.ExecuteTemplate(w, "report", report)
+// Report.WriteHTML renders the HTML report to w.
+func (report Report) WriteHTML(w io.Writer, config Config) error {
+	t, err := loadTemplate(config)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	return t.ExecuteTemplate(w, "report", report)
+}
+
+// Report.Render renders an HTMl report.
+func (report Report) Render(config Config) error {
+	return report.renderFile(".", FormatHtml, config)
+}
+
+// renderFile renders the report in the given format into the directory dir.
+func (report Report) renderFile(dir string, format string,
+	config Config) error {
+	return writeFile(report.reportPath(dir, format), func(w io.Writer) error {
+		switch format {
+		case FormatHtml:
+			return report.WriteHTML(w, config)
+		case FormatJson:
+			return report.WriteJSON(w)
+		case FormatCsv:
+			return report.CumulativeFlow.WriteCSV(w)
+		}
+		return fmt.Errorf("unknown report format %q", format)
+	})
+}
+
+// reportSchemaVersion is the version of the JSON report schema.
+// It is increased for incompatible schema changes.
+const reportSchemaVersion = 2
+
+// reportDocument is the root object of the JSON report.
+type reportDocument struct {
+	SchemaVersion int `json:"schemaVersion"`
+	Report
+}
 
-	w.Flush()
-	f.Close()
+// Report.WriteJSON writes the report as JSON document to w.
+func (report Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reportDocument{
+		SchemaVersion: reportSchemaVersion,
+		Report:        report,
+	})
 }
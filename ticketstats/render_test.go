@@ -1,19 +1,111 @@
 package ticketstats
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
 	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
-	"time"
 )
 
 func TestLoadTemplate(t *testing.T) {
-	temp := loadTemplate(DefaultConfig())
-	if temp == nil {
-		t.Fail()
+	temp, err := loadTemplate(DefaultConfig())
+	if err != nil || temp == nil {
+		t.FailNow()
 	}
 	if temp.Name() != "report" {
 		t.Fail()
 	}
+
+	config := DefaultConfig()
+	config.Template = "missing.tmpl"
+	_, err = loadTemplate(config)
+	if err == nil {
+		log.Println("TEST: missing template without error")
+		t.Fail()
+	}
+}
+
+func TestLegacyTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "template")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a custom template using the fields before the configurable windows
+	config := DefaultConfig()
+	config.Template = filepath.Join(dir, "custom.tmpl")
+	err = ioutil.WriteFile(config.Template, []byte(`{{ define "report" }}`+
+		`{{ with .Bugs }}{{ .Week.Created }} {{ .Month.Created }}{{ end }}`+
+		`{{ range .Other.Month }} {{ .Type }} {{ .Report.Created }}{{ end }}`+
+		`{{ range .History.Bugs }} {{ .Week.Created }}{{ end }}`+
+		`{{ end }}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bug := NewIssue()
+	bug.Key = "PRJ-1"
+	bug.Type = "Bug"
+	bug.Status = "Open"
+	bug.Created = testNow.AddDate(0, 0, -10)
+	task := NewIssue()
+	task.Key = "PRJ-2"
+	task.Type = "Task"
+	task.Status = "Open"
+	task.Created = testNow.AddDate(0, 0, -2)
+	report, err := GenerateReport([]*Issue{bug, task}, "", config,
+		Options{AsOf: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report.History = HistoryReport([]Snapshot{{Time: testNow,
+		Report: report}}, config)
+
+	var buffer bytes.Buffer
+	err = report.WriteHTML(&buffer, config)
+	if err != nil {
+		log.Println("TEST: legacy template failed", err)
+		t.FailNow()
+	}
+	if buffer.String() != "0 1 Task 1 0" {
+		log.Println("TEST: wrong legacy values", buffer.String())
+		t.Fail()
+	}
+}
+
+func TestStylesheet(t *testing.T) {
+	config := DefaultConfig()
+
+	var buffer bytes.Buffer
+	err := NewReport().WriteHTML(&buffer, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := buffer.String()
+	if !strings.Contains(html, ".card-header-title {") ||
+		strings.Contains(html, "<link rel=\"stylesheet\"") {
+		log.Println("TEST: stylesheet not inlined")
+		t.Fail()
+	}
+
+	config.Stylesheet = "https://example.com/bulma.css"
+	buffer.Reset()
+	err = NewReport().WriteHTML(&buffer, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html = buffer.String()
+	if strings.Contains(html, ".card-header-title {") ||
+		!strings.Contains(html, "href=\"https://example.com/bulma.css\"") {
+		log.Println("TEST: external stylesheet not linked")
+		t.Fail()
+	}
 }
 
 func TestToWarnings(t *testing.T) {
@@ -27,7 +119,7 @@ func TestToWarnings(t *testing.T) {
 	logs := make([]WorkLog, 0)
 	logs = append(logs, WorkLog{
 		Hours:    Work(123.0),
-		Date:     time.Now(),
+		Date:     testNow,
 		Activity: "123456",
 	})
 
@@ -42,7 +134,8 @@ func TestToWarnings(t *testing.T) {
 		InvalidWorkLogs: invalidWork,
 	}
 
-	w := sr.ToWarnings("https://test.url/", DefaultConfig())
+	w := sr.ToWarnings("https://test.url/", testNow, DefaultConfig(),
+		testCalendar)
 
 	if w.Count != 2 {
 		t.Fail()
@@ -73,10 +166,10 @@ func TestToReportIssue(t *testing.T) {
 	issue.Summary = "summary"
 	issue.CustomActivity = "123456"
 	issue.Priority = "prio"
-	issue.Due = time.Now()
+	issue.Due = testNow
 	issue.OriginalEstimate = Work(8.0)
 	issue.TimeSpend = Work(4.0)
-	issue.Created = time.Now().AddDate(0, 0, -7)
+	issue.Created = testNow.AddDate(0, 0, -7)
 	issue.Labels = append(issue.Labels, "label")
 	issue.Creator = "aCreator"
 	issue.Assignee = "aAssignee"
@@ -84,7 +177,8 @@ func TestToReportIssue(t *testing.T) {
 	issue.FixVersions = append(issue.FixVersions, "1.2.3")
 	issue.Childs = append(issue.Childs, cissue, cissue2)
 
-	ri := issue.ToReportIssue("https://test.url/", DefaultConfig())
+	ri := issue.ToReportIssue("https://test.url/", testNow, DefaultConfig(),
+		testCalendar)
 
 	if ri.Key != "A" {
 		t.Fail()
@@ -188,10 +282,10 @@ func TestFlattenTree(t *testing.T) {
 	issue.Summary = "summary"
 	issue.CustomActivity = "123456"
 	issue.Priority = "prio"
-	issue.Due = time.Now()
+	issue.Due = testNow
 	issue.OriginalEstimate = Work(8.0)
 	issue.TimeSpend = Work(4.0)
-	issue.Created = time.Now().AddDate(0, 0, -7)
+	issue.Created = testNow.AddDate(0, 0, -7)
 	issue.Labels = append(issue.Labels, "label")
 	issue.Creator = "aCreator"
 	issue.Assignee = "aAssignee"
@@ -204,7 +298,8 @@ func TestFlattenTree(t *testing.T) {
 		Url:  "https://test.url/",
 	}
 
-	childs := flattenTree(issue, parent, "https://jira.url/", DefaultConfig())
+	childs := flattenTree(issue, parent, "https://jira.url/", testNow,
+		DefaultConfig(), testCalendar)
 
 	if len(childs) != 3 {
 		t.Fail()
@@ -217,15 +312,65 @@ func TestFlattenTree(t *testing.T) {
 }
 
 func TestCovertToFTE(t *testing.T) {
-	fte := covertToFTE(time.Now().AddDate(0, 0, 14), Work(40.0))
+	fte := covertToFTE(testNow.AddDate(0, 0, 14), Work(40.0), testNow,
+		testCalendar)
 	if math.Abs(fte-0.5) > 0.001 {
 		t.Fail()
 	}
 }
 
 func TestConvertToAge(t *testing.T) {
-	days := convertToAge(time.Now().AddDate(0, 0, -20))
+	days := convertToAge(testNow.AddDate(0, 0, -20), testNow)
 	if days != 20 {
 		t.Fail()
 	}
 }
+
+func TestWriteJSON(t *testing.T) {
+	issue := NewIssue()
+	issue.Key = "A"
+	issue.Created = testNow.AddDate(0, 0, -3)
+	issue.OriginalEstimate = 16
+	issue.TimeSpend = 4
+
+	report := NewReport()
+	report.Component = "Module A"
+	report.AsOf = testNow
+	report.OldBugs = append(report.OldBugs,
+		issue.ToReportIssue("", testNow, DefaultConfig(), testCalendar))
+
+	var buffer bytes.Buffer
+	err := report.WriteJSON(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]interface{}
+	err = json.Unmarshal(buffer.Bytes(), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc["schemaVersion"] != float64(reportSchemaVersion) ||
+		doc["component"] != "Module A" ||
+		doc["asOf"] != "2021-11-15T12:00:00Z" {
+		log.Println("TEST: wrong document", doc)
+		t.Fail()
+	}
+	if _, ok := doc["Date"]; ok {
+		log.Println("TEST: formatted date in JSON")
+		t.Fail()
+	}
+
+	oldBugs := doc["oldBugs"].([]interface{})
+	bug := oldBugs[0].(map[string]interface{})
+	if bug["estimateHours"] != 16.0 || bug["timeSpendHours"] != 4.0 ||
+		bug["created"] != "2021-11-12T12:00:00Z" || bug["ageDays"] != 3.0 {
+		log.Println("TEST: wrong issue values", bug)
+		t.Fail()
+	}
+	if _, ok := bug["due"]; ok {
+		log.Println("TEST: due date without due")
+		t.Fail()
+	}
+}
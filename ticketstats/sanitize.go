@@ -12,6 +12,10 @@ type SanitizeResult struct {
 	NoActivity []*Issue
 	// invalid time bookings
 	InvalidWorkLogs []InvalidWorkLog
+	// work logs of the export which couldn't be parsed
+	RejectedWorkLogs []*WorkLogError
+	// dates of the export which couldn't be parsed
+	InvalidDates []*DateError
 }
 
 // InvalidWorkLog groups all data for an invalid work log.
@@ -30,8 +34,9 @@ func NewInvalidWorkLog(issue *Issue) InvalidWorkLog {
 
 // AreBookingsValid checks if the work logs of the issue are consistent.
 // The first value of the result is true if all logs are ok, the second
-// is a list of invalid logs.
-func (issue *Issue) AreBookingsValid(ignoreOld bool,
+// is a list of invalid logs. If ignoreOld is set, only the logs of the
+// current and the previous month before now are checked.
+func (issue *Issue) AreBookingsValid(ignoreOld bool, now time.Time,
 	config Config) (bool, []WorkLog) {
 
 	activity := strings.TrimSpace(issue.CustomActivity)
@@ -42,14 +47,14 @@ func (issue *Issue) AreBookingsValid(ignoreOld bool,
 		return true, invalidLogs
 	}
 
-	start := time.Now().AddDate(0, 0, -time.Now().Day()-1)
+	start := now.AddDate(0, 0, -now.Day()-1)
 	for _, l := range issue.LogWorks {
 		if ignoreOld && l.Date.Before(start) {
 			continue
 		}
 		if l.Activity == "" {
-			log.Println("ERROR: WorkLog without activity!",
-				l.ToString(config), issue.Key)
+			log.Println("ERROR: WorkLog without activity!", issue.Key,
+				l.Date.Format(config.Formats.Date), l.Author)
 		}
 
 		if strings.Compare(l.Activity, activity) != 0 {
@@ -62,29 +67,37 @@ func (issue *Issue) AreBookingsValid(ignoreOld bool,
 }
 
 // Sanitize checks all issues for invalid state
-func Sanitize(issues []*Issue, ignoreOld bool, config Config) SanitizeResult {
+func Sanitize(issues []*Issue, ignoreOld bool, now time.Time,
+	config Config) SanitizeResult {
 	noActivity := make([]*Issue, 0)
 	invalidLogs := make([]InvalidWorkLog, 0)
+	rejectedLogs := make([]*WorkLogError, 0)
+	invalidDates := make([]*DateError, 0)
 
 	for _, issue := range issues {
+		rejectedLogs = append(rejectedLogs, issue.RejectedWorkLogs...)
+		invalidDates = append(invalidDates, issue.DateErrors...)
+
 		// Check if activity of ticket can be found
 		if issue.CustomActivity != "" {
 			// Check tickets for wrong time bookings
-			valid, logs := issue.AreBookingsValid(ignoreOld, config)
+			valid, logs := issue.AreBookingsValid(ignoreOld, now, config)
 			if !valid {
 				invalidLog := NewInvalidWorkLog(issue)
 				invalidLog.Logs = append(invalidLog.Logs, logs...)
 				invalidLogs = append(invalidLogs, invalidLog)
 			}
 		} else {
-			if issue.Status != config.States.Closed {
+			if !config.IsClosed(issue) {
 				noActivity = append(noActivity, issue)
 			}
 		}
 	}
 
 	return SanitizeResult{
-		NoActivity:      noActivity,
-		InvalidWorkLogs: invalidLogs,
+		NoActivity:       noActivity,
+		InvalidWorkLogs:  invalidLogs,
+		RejectedWorkLogs: rejectedLogs,
+		InvalidDates:     invalidDates,
 	}
 }
@@ -3,7 +3,6 @@ package ticketstats
 import (
 	"log"
 	"testing"
-	"time"
 )
 
 func TestAreBookingsValid(t *testing.T) {
@@ -15,16 +14,16 @@ func TestAreBookingsValid(t *testing.T) {
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Activity: "123457",
 		Hours:    2.5,
-		Date:     time.Now(),
+		Date:     testNow,
 	})
 
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Activity: "123457",
 		Hours:    3.5,
-		Date:     time.Now(),
+		Date:     testNow,
 	})
 
-	valid, _ := issue.AreBookingsValid(false, config)
+	valid, _ := issue.AreBookingsValid(false, testNow, config)
 
 	if !valid {
 		log.Println("TEST: issue should be valid")
@@ -34,10 +33,10 @@ func TestAreBookingsValid(t *testing.T) {
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Activity: "123456",
 		Hours:    1.5,
-		Date:     time.Now(),
+		Date:     testNow,
 	})
 
-	valid, logs := issue.AreBookingsValid(false, config)
+	valid, logs := issue.AreBookingsValid(false, testNow, config)
 
 	if valid {
 		log.Println("TEST: issue should be invalid")
@@ -63,13 +62,13 @@ func TestSanitize(t *testing.T) {
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Activity: "123457",
 		Hours:    2.5,
-		Date:     time.Now(),
+		Date:     testNow,
 	})
 
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Activity: "123457",
 		Hours:    3.5,
-		Date:     time.Now(),
+		Date:     testNow,
 	})
 
 	issues = append(issues, issue)
@@ -81,7 +80,7 @@ func TestSanitize(t *testing.T) {
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Activity: "123456",
 		Hours:    1.5,
-		Date:     time.Now(),
+		Date:     testNow,
 	})
 
 	issues = append(issues, issue)
@@ -91,7 +90,7 @@ func TestSanitize(t *testing.T) {
 
 	issues = append(issues, issue)
 
-	result := Sanitize(issues, false, DefaultConfig())
+	result := Sanitize(issues, false, testNow, DefaultConfig())
 
 	if len(result.NoActivity) != 1 {
 		log.Println("TEST: wrong count of issues with no activity")
@@ -127,12 +126,12 @@ func TestIgnoreOld(t *testing.T) {
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Activity: "123456",
 		Hours:    1.5,
-		Date:     time.Now().AddDate(0, -2, 0),
+		Date:     testNow.AddDate(0, -2, 0),
 	})
 
 	issues = append(issues, issue)
 
-	result := Sanitize(issues, true, DefaultConfig())
+	result := Sanitize(issues, true, testNow, DefaultConfig())
 
 	if len(result.InvalidWorkLogs) != 0 {
 		log.Println("TEST: wrong count of issues with invalid bookings")
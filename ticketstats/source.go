@@ -0,0 +1,193 @@
+package ticketstats
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Input formats of the Jira exports.
+const (
+	// CSV export of the issue navigator
+	InputCsv = "csv"
+	// XML (RSS) export of the issue navigator
+	InputXml = "xml"
+	// saved REST search responses
+	InputJson = "json"
+)
+
+// IssueSource provides the issues which are evaluated.
+// A source maps the data of a Jira export or the Jira REST API to the
+// internal Issue data objects.
+type IssueSource interface {
+	// Issues loads all issues of the source.
+	Issues() ([]*Issue, error)
+}
+
+// ContextIssueSource is an IssueSource which supports canceling the
+// loading of the issues, e.g. for sources using network requests.
+type ContextIssueSource interface {
+	IssueSource
+	// IssuesContext loads all issues of the source, until ctx is done.
+	IssuesContext(ctx context.Context) ([]*Issue, error)
+}
+
+// loadSource loads the issues of the source, using ctx if the source
+// supports it.
+func loadSource(ctx context.Context, source IssueSource) ([]*Issue, error) {
+	if cs, ok := source.(ContextIssueSource); ok {
+		return cs.IssuesContext(ctx)
+	}
+	err := ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+	return source.Issues()
+}
+
+// InputFormat returns the input format of the export at path, using the
+// file extension: ".xml" and ".rss" are XML exports, ".json" files are
+// REST responses and all other files CSV exports.
+func InputFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml", ".rss":
+		return InputXml
+	case ".json":
+		return InputJson
+	}
+	return InputCsv
+}
+
+// ParseInput parses the export data in the given input format. An empty
+// format is a CSV export.
+func ParseInput(r io.Reader, format string, config Config) ([]*Issue,
+	error) {
+	switch format {
+	case InputCsv, "":
+		return ParseCsv(r, config)
+	case InputXml:
+		return ParseXml(r, config)
+	case InputJson:
+		return ParseJson(r, config)
+	}
+	return nil, fmt.Errorf("unknown input format %q", format)
+}
+
+// parseInputFile parses the export at path in the given input format. If
+// the format is empty, it is chosen by the file extension.
+func parseInputFile(path string, format string, config Config) ([]*Issue,
+	error) {
+	if format == "" {
+		format = InputFormat(path)
+	}
+	if format == InputCsv {
+		return ParseFile(path, config)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read input file: %v", err)
+	}
+	defer f.Close()
+
+	issues, err := ParseInput(f, format, config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return issues, nil
+}
+
+// FileSource reads the issues from Jira exports, i.e. CSV or XML exports
+// or saved REST responses. Path is a comma separated list of files or glob
+// patterns, e.g. for exports split into several files. The issues of
+// several files are merged by issue key, see MergeIssues.
+type FileSource struct {
+	Path string
+	// input format of all files, if empty it is chosen by file extension
+	Format string
+	Config Config
+}
+
+// NewFileSource creates a new FileSource for the exports at path.
+func NewFileSource(path string, format string, config Config) *FileSource {
+	return &FileSource{
+		Path:   path,
+		Format: format,
+		Config: config,
+	}
+}
+
+// FileSource.Issues parses and merges the exports.
+func (source *FileSource) Issues() ([]*Issue, error) {
+	files, err := expandPaths(source.Path)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 1 {
+		return parseInputFile(files[0], source.Format, source.Config)
+	}
+
+	sets := make([][]*Issue, 0)
+	for _, file := range files {
+		issues, err := parseInputFile(file, source.Format, source.Config)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, issues)
+	}
+
+	issues, summary := MergeIssues(sets...)
+	log.Println("INFO:", summary.ToString())
+
+	return issues, nil
+}
+
+// CsvSource reads the issues from Jira CSV exports.
+// Path is a comma separated list of files or glob patterns, e.g. for
+// exports split into several files. The issues of several files are
+// merged by issue key, see MergeIssues.
+type CsvSource struct {
+	Path   string
+	Config Config
+}
+
+// NewCsvSource creates a new CsvSource for the export at path.
+func NewCsvSource(path string, config Config) *CsvSource {
+	return &CsvSource{
+		Path:   path,
+		Config: config,
+	}
+}
+
+// CsvSource.Issues parses and merges the CSV exports.
+func (source *CsvSource) Issues() ([]*Issue, error) {
+	return NewFileSource(source.Path, InputCsv, source.Config).Issues()
+}
+
+// ReaderSource reads the issues from Jira export data in the given input
+// format, e.g. an uploaded file.
+type ReaderSource struct {
+	Reader io.Reader
+	// input format, an empty format is a CSV export
+	Format string
+	Config Config
+}
+
+// NewReaderSource creates a new ReaderSource reading from r.
+func NewReaderSource(r io.Reader, format string,
+	config Config) *ReaderSource {
+	return &ReaderSource{
+		Reader: r,
+		Format: format,
+		Config: config,
+	}
+}
+
+// ReaderSource.Issues parses the export data.
+func (source *ReaderSource) Issues() ([]*Issue, error) {
+	return ParseInput(source.Reader, source.Format, source.Config)
+}
@@ -3,6 +3,7 @@ package ticketstats
 import (
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/montanaflynn/stats"
@@ -15,10 +16,12 @@ type Stats struct {
 	Count  int
 }
 
-func (stats Stats) ToString() string {
+// Stats.ToString converts the statistics to a string, the work values are
+// formatted using the working calendar.
+func (stats Stats) ToString(calendar *Calendar) string {
 	return fmt.Sprintf("mean: %s, median: %s, count: %d",
-		formatWork(stats.Mean),
-		formatWork(stats.Median),
+		calendar.FormatWork(stats.Mean),
+		calendar.FormatWork(stats.Median),
 		stats.Count)
 }
 
@@ -30,41 +33,43 @@ type TimeRanges struct {
 	Year    Stats
 }
 
-func (tr TimeRanges) ToString() string {
+// TimeRanges.ToString converts the statistics of all time ranges to a
+// string, the work values are formatted using the working calendar.
+func (tr TimeRanges) ToString(calendar *Calendar) string {
 	str := ""
 
 	stats := tr.Week
 	str += fmt.Sprintf("last week:    mean: %.15s, median: %.15s, %d issues\n",
-		formatWork(stats.Mean),
-		formatWork(stats.Median),
+		calendar.FormatWork(stats.Mean),
+		calendar.FormatWork(stats.Median),
 		stats.Count)
 
 	stats = tr.Month
 	str += fmt.Sprintf("last month:   mean: %.15s, median: %.15s, %d issues\n",
-		formatWork(stats.Mean),
-		formatWork(stats.Median),
+		calendar.FormatWork(stats.Mean),
+		calendar.FormatWork(stats.Median),
 		stats.Count)
 
 	stats = tr.Quarter
 	str += fmt.Sprintf("last quarter: mean: %.15s, median: %.15s, %d issues\n",
-		formatWork(stats.Mean),
-		formatWork(stats.Median),
+		calendar.FormatWork(stats.Mean),
+		calendar.FormatWork(stats.Median),
 		stats.Count)
 
 	stats = tr.Year
 	str += fmt.Sprintf("last year:    mean: %.15s, median: %.15s, %d issues\n",
-		formatWork(stats.Mean),
-		formatWork(stats.Median),
+		calendar.FormatWork(stats.Mean),
+		calendar.FormatWork(stats.Median),
 		stats.Count)
 
 	return str
 }
 
 // OldBugs finds all unresolved bugs older than one month.
-func OldBugs(issues []*Issue, config Config) []*Issue {
+func OldBugs(issues []*Issue, now time.Time, config Config) []*Issue {
 	oldBugs := make([]*Issue, 0)
 
-	bugs := OlderThanOneMonth(FilterByType(issues, config.Types.Bug))
+	bugs := OlderThanOneMonth(FilterByType(issues, config.Types.Bug), now)
 	for _, issue := range bugs {
 		if !issue.IsResolved() {
 			oldBugs = append(oldBugs, issue)
@@ -75,6 +80,8 @@ func OldBugs(issues []*Issue, config Config) []*Issue {
 }
 
 // ResolutionTime calculates resolution time statistics for a ticket list.
+// The resolution time is the booked effort (TimeSpend) of a ticket, for the
+// calendar time from creation to resolution see LeadTime.
 func ResolutionTime(issues []*Issue) Stats {
 	times := make([]float64, 0)
 
@@ -112,47 +119,277 @@ func ResolutionTime(issues []*Issue) Stats {
 }
 
 // ResultionTimesByType calculates the resolution time statistics for each
-// ticket type in the given list.
-func ResultionTimesByType(issues []*Issue) map[string]TimeRanges {
+// ticket type in the given list. The time ranges end at now.
+func ResultionTimesByType(issues []*Issue, now time.Time) map[string]TimeRanges {
 	result := make(map[string]TimeRanges)
 
-	for _, t := range Types(issues) {
-		typeIssues := FilterByType(issues, t)
+	for t, stats := range ResolutionTimesIn(issues, rollingWindows(now)) {
+		result[t] = TimeRanges{
+			Week:    stats[0],
+			Month:   stats[1],
+			Quarter: stats[2],
+			Year:    stats[3],
+		}
+	}
 
-		var tr TimeRanges
+	return result
+}
 
-		typeIssuesRange := ClosedLastYear(typeIssues)
-		tr.Year = ResolutionTime(typeIssuesRange)
+// ResolutionTimesIn calculates the resolution time statistics for each
+// ticket type in the given list and each window. The statistics are ordered
+// like the windows. Types without resolved tickets in all windows are
+// skipped.
+func ResolutionTimesIn(issues []*Issue, windows []Window) map[string][]Stats {
+	result := make(map[string][]Stats)
+
+	set := NewIssueSet(issues)
+	for _, t := range set.Types() {
+		typeIssues := NewIssueSet(set.ByType(t))
+
+		stats := make([]Stats, 0)
+		count := 0
+		for _, window := range windows {
+			s := ResolutionTime(typeIssues.ClosedIn(window))
+			count += s.Count
+			stats = append(stats, s)
+		}
 
-		if tr.Year.Count == 0 {
+		if count == 0 {
 			// no booked work hours
 			continue
 		}
+		result[t] = stats
+	}
+
+	return result
+}
+
+// WorkAfter sums all work done after a given start date.
+func WorkAfter(issues []*Issue, start time.Time) Work {
+	var work Work
+	for _, issue := range issues {
+		for _, log := range issue.LogWorks {
+			if log.Date.After(start) {
+				work += log.Hours
+			}
+		}
+	}
+	return work
+}
+
+// WorkBetween sums all work done after the given start date and not after
+// the given end date.
+func WorkBetween(issues []*Issue, start time.Time, end time.Time) Work {
+	var work Work
+	for _, issue := range issues {
+		for _, log := range issue.LogWorks {
+			if log.Date.After(start) && !log.Date.After(end) {
+				work += log.Hours
+			}
+		}
+	}
+	return work
+}
+
+// FlowStats groups the lead time statistics for a ticket list.
+// The unit is days.
+type FlowStats struct {
+	Mean   float64
+	Median float64
+	P85    float64
+	P95    float64
+	Count  int
+}
+
+// FlowStats.ToString creates a string representation of the lead time
+// statistics.
+func (stats FlowStats) ToString() string {
+	return fmt.Sprintf("mean: %.1fd, median: %.1fd, p85: %.1fd, p95: %.1fd, "+
+		"count: %d",
+		stats.Mean,
+		stats.Median,
+		stats.P85,
+		stats.P95,
+		stats.Count)
+}
+
+// Issue.LeadTime returns the calendar time from creation to resolution as
+// days. The lead time of unresolved issues is 0.
+func (issue *Issue) LeadTime() float64 {
+	if !issue.IsResolved() || issue.Created.IsZero() {
+		return 0
+	}
+	return issue.Resolved.Sub(issue.Created).Hours() / 24
+}
+
+// LeadTime calculates the lead time statistics for a ticket list.
+// Unresolved tickets are ignored.
+func LeadTime(issues []*Issue) FlowStats {
+	times := make([]float64, 0)
+
+	for _, issue := range issues {
+		if issue.IsResolved() && !issue.Created.IsZero() {
+			times = append(times, issue.LeadTime())
+		}
+	}
+
+	if len(times) == 0 {
+		return FlowStats{}
+	}
 
-		typeIssuesRange = ClosedLastQuarter(typeIssuesRange)
-		tr.Quarter = ResolutionTime(typeIssuesRange)
+	mean, err := stats.Mean(times)
+	if err != nil {
+		log.Println("ERROR: mean of lead time", err)
+	}
+
+	median, err := stats.Median(times)
+	if err != nil {
+		log.Println("ERROR: median of lead time", err)
+	}
+
+	p85, err := stats.PercentileNearestRank(times, 85)
+	if err != nil {
+		log.Println("ERROR: p85 of lead time", err)
+	}
+
+	p95, err := stats.PercentileNearestRank(times, 95)
+	if err != nil {
+		log.Println("ERROR: p95 of lead time", err)
+	}
 
-		typeIssuesRange = ClosedLastMonth(typeIssuesRange)
-		tr.Month = ResolutionTime(typeIssuesRange)
+	return FlowStats{
+		Mean:   mean,
+		Median: median,
+		P85:    p85,
+		P95:    p95,
+		Count:  len(times),
+	}
+}
 
-		typeIssuesRange = ClosedLastWeek(typeIssuesRange)
-		tr.Week = ResolutionTime(typeIssuesRange)
+// LeadTimesIn calculates the lead time statistics for each group of the
+// given tickets and each window. The groups of a ticket are provided by the
+// groups function, e.g. the components of the ticket. The statistics are
+// ordered like the windows.
+func LeadTimesIn(issues []*Issue, groups func(issue *Issue) []string,
+	windows []Window) map[string][]FlowStats {
+	result := make(map[string][]FlowStats)
 
-		result[t] = tr
+	grouped := make(map[string][]*Issue)
+	for _, issue := range issues {
+		closed := false
+		for _, window := range windows {
+			if window.Contains(issue.Resolved) {
+				closed = true
+				break
+			}
+		}
+		if !closed {
+			continue
+		}
+		for _, group := range groups(issue) {
+			grouped[group] = append(grouped[group], issue)
+		}
+	}
+
+	for group, groupIssues := range grouped {
+		set := NewIssueSet(groupIssues)
+		stats := make([]FlowStats, 0)
+		for _, window := range windows {
+			stats = append(stats, LeadTime(set.ClosedIn(window)))
+		}
+		result[group] = stats
 	}
 
 	return result
 }
 
-// WorkAfter sums all work done after a given start date.
-func WorkAfter(issues []*Issue, start time.Time) Work {
+// LeadTimesByType calculates the lead time statistics for each ticket type
+// and window, see LeadTimesIn.
+func LeadTimesByType(issues []*Issue,
+	windows []Window) map[string][]FlowStats {
+	return LeadTimesIn(issues, func(issue *Issue) []string {
+		return []string{issue.Type}
+	}, windows)
+}
+
+// LeadTimesByPriority calculates the lead time statistics for each ticket
+// priority and window, see LeadTimesIn.
+func LeadTimesByPriority(issues []*Issue,
+	windows []Window) map[string][]FlowStats {
+	return LeadTimesIn(issues, func(issue *Issue) []string {
+		return []string{issue.Priority}
+	}, windows)
+}
+
+// LeadTimesByComponent calculates the lead time statistics for each
+// component and window, see LeadTimesIn.
+func LeadTimesByComponent(issues []*Issue,
+	windows []Window) map[string][]FlowStats {
+	return LeadTimesIn(issues, func(issue *Issue) []string {
+		return issue.Components
+	}, windows)
+}
+
+// WorkByAuthorBetween sums all work of the given author done after the given
+// start date and not after the given end date.
+func WorkByAuthorBetween(issues []*Issue, author string, start time.Time,
+	end time.Time) Work {
 	var work Work
 	for _, issue := range issues {
 		for _, log := range issue.LogWorks {
-			if log.Date.After(start) {
+			if log.Author == author && log.Date.After(start) &&
+				!log.Date.After(end) {
 				work += log.Hours
 			}
 		}
 	}
 	return work
 }
+
+// WorkByAuthor sums the work of the given author done after the given start
+// date and not after the given end date. The work is grouped by the given
+// key function, e.g. the work log activity or the ticket type.
+func WorkByAuthor(issues []*Issue, author string, start time.Time,
+	end time.Time, key func(issue *Issue, log WorkLog) string) map[string]Work {
+	work := make(map[string]Work)
+	for _, issue := range issues {
+		for _, log := range issue.LogWorks {
+			if log.Author == author && log.Date.After(start) &&
+				!log.Date.After(end) {
+				work[key(issue, log)] += log.Hours
+			}
+		}
+	}
+	return work
+}
+
+// DailyWork groups the work done by a person on a day.
+type DailyWork struct {
+	Date  time.Time
+	Hours Work
+}
+
+// Overbookings returns the days after the given start date and not after
+// the given end date, on which the given author booked more than max hours.
+// The days are sorted by date.
+func Overbookings(issues []*Issue, author string, start time.Time,
+	end time.Time, max Work) []DailyWork {
+	days := WorkByAuthor(issues, author, start, end,
+		func(issue *Issue, log WorkLog) string {
+			return log.Date.Format("2006-01-02")
+		})
+
+	result := make([]DailyWork, 0)
+	for day, hours := range days {
+		if hours <= max {
+			continue
+		}
+		date, _ := time.Parse("2006-01-02", day)
+		result = append(result, DailyWork{Date: date, Hours: hours})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date.Before(result[j].Date)
+	})
+
+	return result
+}
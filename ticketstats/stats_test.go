@@ -2,8 +2,8 @@ package ticketstats
 
 import (
 	"log"
+	"strings"
 	"testing"
-	"time"
 )
 
 func TestOldBugs(t *testing.T) {
@@ -12,35 +12,35 @@ func TestOldBugs(t *testing.T) {
 	issue := NewIssue()
 	issue.Key = "A"
 	issue.Type = "Bug"
-	issue.Created = time.Now().AddDate(0, 0, -5)
+	issue.Created = testNow.AddDate(0, 0, -5)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "B"
 	issue.Type = "Bug"
-	issue.Created = time.Now().AddDate(0, 0, -25)
+	issue.Created = testNow.AddDate(0, 0, -25)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "C"
 	issue.Type = "Bug"
-	issue.Created = time.Now().AddDate(0, -1, -1)
+	issue.Created = testNow.AddDate(0, -1, -1)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "D"
 	issue.Type = "Task"
-	issue.Created = time.Now().AddDate(0, -1, -1)
+	issue.Created = testNow.AddDate(0, -1, -1)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.Key = "A"
 	issue.Type = "Bug"
-	issue.Resolved = time.Now().AddDate(0, 0, -1)
-	issue.Created = time.Now().AddDate(0, -1, -5)
+	issue.Resolved = testNow.AddDate(0, 0, -1)
+	issue.Created = testNow.AddDate(0, -1, -5)
 	issues = append(issues, issue)
 
-	old := OldBugs(issues, DefaultConfig())
+	old := OldBugs(issues, testNow, DefaultConfig())
 
 	if len(old) != 1 {
 		log.Println("TEST: wrong count of old bugs")
@@ -94,28 +94,28 @@ func TestResultionTimesByType(t *testing.T) {
 	issue := NewIssue()
 	issue.TimeSpend = Work(10.0)
 	issue.Type = "Bug"
-	issue.Resolved = time.Now().AddDate(0, -9, 0)
+	issue.Resolved = testNow.AddDate(0, -9, 0)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.TimeSpend = Work(20.0)
 	issue.Type = "Feature"
-	issue.Resolved = time.Now().AddDate(0, -2, 0)
+	issue.Resolved = testNow.AddDate(0, -2, 0)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.TimeSpend = Work(30.0)
 	issue.Type = "Improvement"
-	issue.Resolved = time.Now().AddDate(0, 0, -2)
+	issue.Resolved = testNow.AddDate(0, 0, -2)
 	issues = append(issues, issue)
 
 	issue = NewIssue()
 	issue.TimeSpend = Work(40.0)
 	issue.Type = "Task"
-	issue.Resolved = time.Now().AddDate(0, 0, -20)
+	issue.Resolved = testNow.AddDate(0, 0, -20)
 	issues = append(issues, issue)
 
-	stats := ResultionTimesByType(issues)
+	stats := ResultionTimesByType(issues, testNow)
 
 	bug := stats["Bug"]
 	if bug.Year.Count != 1 ||
@@ -212,17 +212,17 @@ func TestWorkAfter(t *testing.T) {
 	issue := NewIssue()
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Hours:    10.0,
-		Date:     time.Now().AddDate(0, 0, -2),
+		Date:     testNow.AddDate(0, 0, -2),
 		Activity: "123456",
 	})
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Hours:    20.0,
-		Date:     time.Now().AddDate(0, 0, -4),
+		Date:     testNow.AddDate(0, 0, -4),
 		Activity: "123456",
 	})
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Hours:    30.0,
-		Date:     time.Now().AddDate(0, 0, -10),
+		Date:     testNow.AddDate(0, 0, -10),
 		Activity: "123456",
 	})
 	issues = append(issues, issue)
@@ -230,19 +230,194 @@ func TestWorkAfter(t *testing.T) {
 	issue = NewIssue()
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Hours:    40.0,
-		Date:     time.Now().AddDate(0, 0, -3),
+		Date:     testNow.AddDate(0, 0, -3),
 		Activity: "123456",
 	})
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Hours:    50.0,
-		Date:     time.Now().AddDate(0, 0, -11),
+		Date:     testNow.AddDate(0, 0, -11),
 		Activity: "123456",
 	})
 	issues = append(issues, issue)
 
-	work := WorkAfter(issues, time.Now().AddDate(0, 0, -7))
+	work := WorkAfter(issues, testNow.AddDate(0, 0, -7))
 
 	if work != Work(70.0) {
 		t.Fail()
 	}
 }
+
+func TestWorkBetween(t *testing.T) {
+	issue := NewIssue()
+	issue.LogWorks = append(issue.LogWorks, WorkLog{
+		Hours: 2.0,
+		Date:  testNow.AddDate(0, 0, -2),
+	})
+	issue.LogWorks = append(issue.LogWorks, WorkLog{
+		Hours: 3.0,
+		Date:  testNow.AddDate(0, 0, 2),
+	})
+
+	work := WorkBetween([]*Issue{issue}, testNow.AddDate(0, 0, -7), testNow)
+	if work != 2.0 {
+		log.Println("TEST: wrong work", work)
+		t.Fail()
+	}
+}
+
+func TestWorkByAuthor(t *testing.T) {
+	bug := NewIssue()
+	bug.Type = "Bug"
+	bug.LogWorks = append(bug.LogWorks,
+		WorkLog{Hours: 6, Date: testNow.AddDate(0, 0, -1), Author: "a",
+			Activity: "dev"},
+		WorkLog{Hours: 2, Date: testNow.AddDate(0, 0, -2), Author: "b",
+			Activity: "dev"})
+	feature := NewIssue()
+	feature.Type = "New Feature"
+	feature.LogWorks = append(feature.LogWorks,
+		WorkLog{Hours: 5, Date: testNow.AddDate(0, 0, -1), Author: "a",
+			Activity: "test"},
+		WorkLog{Hours: 4, Date: testNow.AddDate(0, -2, 0), Author: "a",
+			Activity: "dev"})
+	issues := []*Issue{bug, feature}
+
+	authors := Authors(issues)
+	if len(authors) != 2 {
+		log.Println("TEST: wrong authors", authors)
+		t.Fail()
+	}
+
+	work := WorkByAuthorBetween(issues, "a", testNow.AddDate(0, 0, -7), testNow)
+	if work != 11 {
+		log.Println("TEST: wrong work", work)
+		t.Fail()
+	}
+
+	byType := WorkByAuthor(issues, "a", testNow.AddDate(0, -3, 0), testNow,
+		func(issue *Issue, log WorkLog) string {
+			return issue.Type
+		})
+	if byType["Bug"] != 6 || byType["New Feature"] != 9 {
+		log.Println("TEST: wrong work by type", byType)
+		t.Fail()
+	}
+
+	days := Overbookings(issues, "a", testNow.AddDate(0, -3, 0), testNow, 10)
+	if len(days) != 1 || days[0].Hours != 11 {
+		log.Println("TEST: wrong overbookings", days)
+		t.Fail()
+	}
+	if len(Overbookings(issues, "b", testNow.AddDate(0, -3, 0), testNow,
+		10)) != 0 {
+		log.Println("TEST: b is overbooked")
+		t.Fail()
+	}
+}
+
+func TestLeadTime(t *testing.T) {
+	issues := make([]*Issue, 0)
+
+	for i := 1; i <= 20; i++ {
+		issue := NewIssue()
+		issue.Created = testNow.AddDate(0, 0, -30)
+		issue.Resolved = issue.Created.AddDate(0, 0, i)
+		issues = append(issues, issue)
+	}
+
+	// not resolved
+	issue := NewIssue()
+	issue.Created = testNow.AddDate(0, 0, -30)
+	issues = append(issues, issue)
+
+	stats := LeadTime(issues)
+
+	if stats.Count != 20 {
+		log.Println("TEST: wrong count", stats.ToString())
+		t.Fail()
+	}
+	if stats.Mean != 10.5 || stats.Median != 10.5 {
+		log.Println("TEST: wrong mean or median", stats.ToString())
+		t.Fail()
+	}
+	if stats.P85 != 17 || stats.P95 != 19 {
+		log.Println("TEST: wrong percentiles", stats.ToString())
+		t.Fail()
+	}
+
+	if LeadTime(make([]*Issue, 0)).Count != 0 {
+		log.Println("TEST: empty list")
+		t.Fail()
+	}
+}
+
+func TestLeadTimesBy(t *testing.T) {
+	issues := make([]*Issue, 0)
+
+	issue := NewIssue()
+	issue.Type = "Bug"
+	issue.Priority = "High"
+	issue.Components = append(issue.Components, "A", "B")
+	issue.Created = testNow.AddDate(0, 0, -6)
+	issue.Resolved = testNow.AddDate(0, 0, -2)
+	issues = append(issues, issue)
+
+	issue = NewIssue()
+	issue.Type = "Bug"
+	issue.Priority = "Low"
+	issue.Components = append(issue.Components, "A")
+	issue.Created = testNow.AddDate(0, -3, 0)
+	issue.Resolved = testNow.AddDate(0, -2, 0)
+	issues = append(issues, issue)
+
+	// last week, month, quarter and year
+	windows := rollingWindows(testNow)
+	byType := LeadTimesByType(issues, windows)
+	bugs := byType["Bug"]
+	if len(bugs) != 4 || bugs[3].Count != 2 || bugs[2].Count != 2 ||
+		bugs[1].Count != 1 || bugs[0].Count != 1 {
+		log.Println("TEST: wrong type ranges", bugs)
+		t.FailNow()
+	}
+	if bugs[0].Mean != 4 {
+		log.Println("TEST: wrong week lead time", bugs[0].ToString())
+		t.Fail()
+	}
+
+	byPriority := LeadTimesByPriority(issues, windows)
+	if len(byPriority) != 2 || byPriority["Low"][0].Count != 0 {
+		log.Println("TEST: wrong priorities", byPriority)
+		t.Fail()
+	}
+
+	byComponent := LeadTimesByComponent(issues, windows)
+	if byComponent["A"][3].Count != 2 || byComponent["B"][3].Count != 1 {
+		log.Println("TEST: wrong components", byComponent)
+		t.Fail()
+	}
+}
+
+func TestStatsToString(t *testing.T) {
+	config := DefaultConfig()
+	config.Calendar.HoursPerDay = 6
+	config.Calendar.WorkingDays = []string{"Monday", "Tuesday", "Wednesday"}
+	calendar, err := NewCalendar(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := Stats{Mean: 21, Median: 6, Count: 2}
+	str := stats.ToString(calendar)
+	if str != "mean: 1w 3.00h, median: 1d , count: 2" {
+		log.Println("TEST: wrong stats string", str)
+		t.Fail()
+	}
+
+	ranges := TimeRanges{Week: stats}
+	str = ranges.ToString(calendar)
+	if !strings.HasPrefix(str,
+		"last week:    mean: 1w 3.00h, median: 1d , 2 issues\n") {
+		log.Println("TEST: wrong time ranges string", str)
+		t.Fail()
+	}
+}
@@ -3,7 +3,9 @@
 package ticketstats
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"sort"
 	"strings"
@@ -12,15 +14,102 @@ import (
 
 // TicketStats groups all data of a program run.
 type TicketStats struct {
-	config    Config
-	jiraBase  string
-	issues    []*Issue
+	config   Config
+	jiraBase string
+	now      time.Time
+	windows  []Window
+	calendar *Calendar
+	// filters of the report sections, see config.Filters
+	filters map[string]Predicate
+	issues  []*Issue
+	// indexed issues, see sectionSet
+	set       *IssueSet
 	active    []*Issue
 	report    Report
 	ignoreOld bool
 }
 
+// Options groups the settings of a program run.
+type Options struct {
+	// Jira project key to filter the issue set
+	Project string
+	// component name to filter the issue set
+	Component string
+	// filter expression to reduce the issue set, see ParseQuery
+	Filter string
+	// Jira base URL to generate links
+	JiraBase string
+	// generate a report for each component of the issue set
+	SplitByComponent bool
+	// reference time for all time windows and ages, zero means now
+	AsOf time.Time
+	// comma separated list of report formats ("html", "json"), default html
+	Format string
+	// issue source evaluated by Run
+	Source IssueSource
+	// Jira export data evaluated by Run, if Source is not set
+	Input io.Reader
+	// input format of Input ("csv", "xml" or "json"), default csv
+	InputFormat string
+	// optional changelog CSV export, see ParseChangelog
+	Changelog io.Reader
+	// configuration used by Run, DefaultConfig() if not set
+	Config *Config
+	// directory for the report files written by Run, if empty Run
+	// doesn't write any report files
+	OutputDir string
+}
+
+// Result groups the results of Run.
+type Result struct {
+	// evaluated issues, reduced to the project and component
+	Issues []*Issue
+	// the report of all issues, followed by the reports of the components
+	// if the issues are split by component
+	Reports []Report
+}
+
+// Result.Report returns the report of the given component.
+func (result *Result) Report(component string) (Report, bool) {
+	for _, report := range result.Reports {
+		if report.Component == component {
+			return report, true
+		}
+	}
+	return Report{}, false
+}
+
+// Report formats supported by Options.Format.
+const (
+	FormatHtml = "html"
+	FormatJson = "json"
+	// cumulative flow series only
+	FormatCsv = "csv"
+)
+
+// parseFormats splits and validates a comma separated format list.
+func parseFormats(format string) ([]string, error) {
+	formats := make([]string, 0)
+	for _, f := range strings.Split(format, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		switch f {
+		case "":
+			continue
+		case FormatHtml, FormatJson, FormatCsv:
+			formats = append(formats, f)
+		default:
+			return nil, fmt.Errorf("unknown report format %q", f)
+		}
+	}
+	if len(formats) == 0 {
+		formats = append(formats, FormatHtml)
+	}
+	return formats, nil
+}
+
 // Evaluate generates a full report for the exported tickets.
+// The config is loaded from the working directory and errors are only
+// logged, use Run for an evaluation without these side effects.
 func Evaluate(path string,
 	project string,
 	component string,
@@ -30,58 +119,269 @@ func Evaluate(path string,
 	config := LoadConfig()
 
 	// read issues form csv
-	issues := Parse(path, config)
+	source := NewCsvSource(path, config)
+
+	options := Options{
+		Project:          project,
+		Component:        component,
+		JiraBase:         jiraBase,
+		SplitByComponent: splitByComponent,
+	}
+
+	issues, err := LoadIssues(source, config, options)
+	if err != nil {
+		log.Println("ERROR:", err)
+		return
+	}
+	PrintClusters(issues, config)
+
+	err = EvaluateIssues(issues, config, options)
+	if err != nil {
+		log.Println("ERROR:", err)
+	}
+}
+
+// Run loads and evaluates the issues of options.Source or options.Input
+// and returns the reports. Run doesn't exit or panic, and it touches the
+// filesystem only if asked to, i.e. if options.OutputDir is set or if the
+// config defines a history directory.
+func Run(ctx context.Context, options Options) (*Result, error) {
+	config := DefaultConfig()
+	if options.Config != nil {
+		config = *options.Config
+	}
+
+	formats, err := parseFormats(options.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	source := options.Source
+	if source == nil {
+		if options.Input == nil {
+			return nil, fmt.Errorf("no issue source")
+		}
+		source = NewReaderSource(options.Input, options.InputFormat, config)
+	}
+
+	issues, err := loadIssues(ctx, source, config, options)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := evaluate(ctx, issues, config, options, formats)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		Issues:  issues,
+		Reports: reports,
+	}, nil
+}
+
+// LoadIssues loads the issues of the source and reduces them to the
+// project and component of the options or the config. The loaded issues
+// are clustered.
+func LoadIssues(source IssueSource, config Config,
+	options Options) ([]*Issue, error) {
+	return loadIssues(context.Background(), source, config, options)
+}
+
+// loadIssues implements LoadIssues, the loading is canceled if ctx is done.
+func loadIssues(ctx context.Context, source IssueSource, config Config,
+	options Options) ([]*Issue, error) {
+	issues, err := loadSource(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Changelog != nil {
+		changes, err := ParseChangelog(options.Changelog, config)
+		if err != nil {
+			return nil, fmt.Errorf("changelog: %v", err)
+		}
+		AddChangelog(issues, changes)
+	}
 
+	project := options.Project
 	if project == "" {
 		project = config.Project
 	}
 	if project != "" {
 		issues = FilterByProject(issues, project)
 	}
-	if component == "" {
-		component = config.Component
-	}
+	component := reportComponent(config, options)
 	if component != "" {
 		issues = FilterByComponent(issues, component)
-		splitByComponent = false
+	}
+	if options.Filter != "" {
+		issues, err = FilterByQuery(issues, options.Filter,
+			reportTime(options), config)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	ClusterIssues(issues)
-	PrintClusters(issues, config)
+	ClusterIssues(issues, config)
+
+	return issues, nil
+}
+
+// reportComponent returns the component of the options or the config.
+func reportComponent(config Config, options Options) string {
+	if options.Component != "" {
+		return options.Component
+	}
+	return config.Component
+}
+
+// reportTime returns the reference time of the options, i.e. now if no
+// time is set.
+func reportTime(options Options) time.Time {
+	if options.AsOf.IsZero() {
+		return time.Now()
+	}
+	return options.AsOf
+}
+
+// EvaluateSource generates a full report for the tickets provided by the
+// given source.
+func EvaluateSource(source IssueSource, config Config, options Options) error {
+	_, err := parseFormats(options.Format)
+	if err != nil {
+		return err
+	}
+
+	issues, err := LoadIssues(source, config, options)
+	if err != nil {
+		return err
+	}
+
+	return EvaluateIssues(issues, config, options)
+}
+
+// EvaluateIssues generates and renders the reports for the loaded issues,
+// see LoadIssues. If the issues are split by component, an additional
+// report is generated for each component. The reports are written to
+// options.OutputDir, default is the working directory.
+func EvaluateIssues(issues []*Issue, config Config, options Options) error {
+	formats, err := parseFormats(options.Format)
+	if err != nil {
+		return err
+	}
+	if options.OutputDir == "" {
+		options.OutputDir = "."
+	}
+
+	_, err = evaluate(context.Background(), issues, config, options, formats)
+	return err
+}
+
+// evaluate generates the reports for the loaded issues. The reports are
+// rendered if options.OutputDir is set.
+func evaluate(ctx context.Context, issues []*Issue, config Config,
+	options Options, formats []string) ([]Report, error) {
+	component := reportComponent(config, options)
+	splitByComponent := options.SplitByComponent && component == ""
+
+	// invalid section filters fail the evaluation instead of being ignored
+	filters, err := config.SectionFilters(reportTime(options))
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := NewCalendar(config)
+	if err != nil {
+		return nil, fmt.Errorf("calendar: %v", err)
+	}
+
+	components := []string{component}
+	if splitByComponent {
+		components = append(components, Components(issues)...)
+	}
+
+	reports := make([]Report, 0)
+	for i, c := range components {
+		err := ctx.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		ts := newTicketStats(issues, c, config, options, filters, calendar)
+		if i > 0 {
+			ts.issues = FilterByComponent(issues, c)
+		}
+		ts.generateReport()
+		err = ts.history()
+		if err != nil {
+			return nil, err
+		}
+		if options.OutputDir != "" {
+			for _, format := range formats {
+				err = ts.report.renderFile(options.OutputDir, format, config)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		reports = append(reports, ts.report)
+	}
+
+	return reports, nil
+}
+
+// GenerateReport generates the report data for the issues, without storing
+// a history snapshot and without rendering the report. An error is returned
+// for invalid section filters, see config.Filters, and an invalid working
+// calendar, see config.Calendar.
+func GenerateReport(issues []*Issue, component string, config Config,
+	options Options) (Report, error) {
+	filters, err := config.SectionFilters(reportTime(options))
+	if err != nil {
+		return Report{}, err
+	}
+	calendar, err := NewCalendar(config)
+	if err != nil {
+		return Report{}, fmt.Errorf("calendar: %v", err)
+	}
+
+	ts := newTicketStats(issues, component, config, options, filters,
+		calendar)
+	ts.generateReport()
+	return ts.report, nil
+}
+
+// newTicketStats initializes the TicketStats for a report of the issues.
+// The filters are the compiled section filters, see Config.SectionFilters.
+func newTicketStats(issues []*Issue, component string, config Config,
+	options Options, filters map[string]Predicate,
+	calendar *Calendar) TicketStats {
+	now := reportTime(options)
 
 	ts := TicketStats{
 		config:   config,
-		jiraBase: jiraBase,
+		filters:  filters,
+		jiraBase: options.JiraBase,
+		now:      now,
+		windows:  config.TimeWindows(now),
+		calendar: calendar,
 		issues:   issues,
 		report:   NewReport(),
 	}
 	ts.report.Component = component
-	ts.report.Date = time.Now().Format(config.Formats.Date)
+	ts.report.Date = now.Format(config.Formats.Date)
+	ts.report.AsOf = now
 	ts.ignoreOld = true
-	ts.generateReport()
 
-	if splitByComponent {
-		for _, component := range Components(issues) {
-			issues = FilterByComponent(issues, component)
-
-			ts = TicketStats{
-				config:   DefaultConfig(),
-				jiraBase: jiraBase,
-				issues:   FilterByComponent(issues, component),
-				report:   NewReport(),
-			}
-			ts.report.Component = component
-			ts.report.Date = time.Now().Format(config.Formats.Date)
-			ts.ignoreOld = true
-			ts.generateReport()
-		}
-	}
+	return ts
 }
 
-// generateReport generates a full report.
+// generateReport generates the data of all report sections.
 func (ts *TicketStats) generateReport() {
+	ts.set = NewIssueSet(ts.issues)
+
 	// Reduce to active tickets
-	ts.active = ActiveTickets(ts.issues, ts.config)
+	ts.active = ActiveTickets(ts.issues, ts.now, ts.config)
 	log.Println("INFO:", len(ts.active), "active tickets.")
 
 	ts.sanitize()
@@ -91,15 +391,71 @@ func (ts *TicketStats) generateReport() {
 	ts.improvements()
 	ts.other()
 	ts.resources()
+	ts.flow()
+	ts.people()
+	ts.statuses()
+	ts.cumulativeFlow()
+}
+
+// section returns the issues of the report section, i.e. the given issues
+// reduced by the filter of the section.
+func (ts *TicketStats) section(name string, issues []*Issue) []*Issue {
+	test, ok := ts.filters[name]
+	if !ok {
+		return issues
+	}
+	return Filter(issues, test)
+}
+
+// sectionSet returns the indexed issues of the report section, see section.
+// Sections without filter share the indexes of all issues.
+func (ts *TicketStats) sectionSet(name string) *IssueSet {
+	if _, ok := ts.filters[name]; !ok {
+		return ts.set
+	}
+	return NewIssueSet(ts.section(name, ts.issues))
+}
+
+// history generates the history report data and stores the snapshot of
+// this run. The history is only generated if a snapshot directory is
+// configured.
+func (ts *TicketStats) history() error {
+	if ts.config.History == "" {
+		return nil
+	}
+
+	store := NewSnapshotStore(ts.config.History)
+	snapshot := Snapshot{
+		Time:      ts.now,
+		Component: ts.report.Component,
+		Report:    ts.report,
+		Issues:    ts.issues,
+	}
 
-	ts.report.Render(ts.config)
+	snapshots, err := store.Load(ts.report.Component, ts.now,
+		historySize-1)
+	if err != nil {
+		return fmt.Errorf("history: %v", err)
+	}
+	snapshots = append(snapshots, snapshot)
+
+	ts.report.History = HistoryReport(snapshots, ts.config)
+	ts.report.HasHistory = len(snapshots) > 1
+
+	err = store.Save(snapshot)
+	if err != nil {
+		return fmt.Errorf("history: %v", err)
+	}
+	return nil
 }
 
 // sanitize checks if the tickets are valid and generate the Warnings report.
 func (ts *TicketStats) sanitize() {
+	issues := ts.section(SectionWarnings, ts.issues)
 	// Check tickets for issues
-	result := Sanitize(ts.issues, ts.ignoreOld, ts.config)
-	ts.report.Warnings = result.ToWarnings(ts.jiraBase, ts.config)
+	result := Sanitize(issues, ts.ignoreOld, ts.now, ts.config)
+	ts.report.Warnings = result.ToWarnings(ts.jiraBase, ts.now, ts.config,
+		ts.calendar)
 	if ts.report.Warnings.Count > 0 {
 		ts.report.HasWarnings = true
 	}
@@ -107,59 +463,38 @@ func (ts *TicketStats) sanitize() {
 
 // oldBugs generates the old bug report data.
 func (ts *TicketStats) oldBugs() {
-	oldBugs := OldBugs(ts.active, ts.config)
+	active := ts.section(SectionOldBugs, ts.active)
+	oldBugs := OldBugs(active, ts.now, ts.config)
 
-	filterStates := ts.config.States.BugFilter
-
-	oldBugs = Filter(oldBugs, func(issue *Issue) bool {
-		keep := true
-		for _, status := range filterStates {
-			if issue.Status == status {
-				keep = false
-				break
-			}
-		}
-		return keep
-	})
+	oldBugs = Filter(oldBugs, Not(ByStatus(ts.config.States.BugFilter...)))
 
 	OrderByCreated(oldBugs)
 	for _, bug := range oldBugs {
 		ts.report.OldBugs = append(ts.report.OldBugs, bug.ToReportIssue(
-			ts.jiraBase, ts.config))
+			ts.jiraBase, ts.now, ts.config, ts.calendar))
 	}
 	log.Println("INFO:", len(oldBugs), "old bug tickets.")
 }
 
 // bugs generates the bug report data.
 func (ts *TicketStats) bugs() {
-	bugs := FilterByType(ts.issues, ts.config.Types.Bug)
-	openBugs := OpenTickets(bugs, ts.config)
-
-	filterStates := ts.config.States.BugFilter
-
-	openBugs = Filter(openBugs, func(issue *Issue) bool {
-		keep := true
-		for _, status := range filterStates {
-			if issue.Status == status {
-				keep = false
-				break
-			}
-		}
-		return keep
-	})
-
-	ts.report.Bugs.Count = len(openBugs)
+	bugs := NewIssueSet(ts.sectionSet(SectionBugs).ByType(
+		ts.config.Types.Bug))
+	openBugs := bugs.Where(And(IsOpen(ts.config),
+		Not(ByStatus(ts.config.States.BugFilter...))))
 
-	ts.report.Bugs.Week.Created = len(CreatedLastWeek(bugs))
-	ts.report.Bugs.Week.Resolved = len(ClosedLastWeek(bugs))
-	ts.report.Bugs.Week.Diff = ts.report.Bugs.Week.Created - ts.report.Bugs.Week.Resolved
+	ts.report.Bugs.Count = openBugs.Len()
 
-	ts.report.Bugs.Month.Created = len(CreatedLastMonth(bugs))
-	ts.report.Bugs.Month.Resolved = len(ClosedLastMonth(bugs))
-	ts.report.Bugs.Month.Diff = ts.report.Bugs.Month.Created - ts.report.Bugs.Month.Resolved
+	for _, window := range ts.windows {
+		ts.report.Bugs.Ranges = append(ts.report.Bugs.Ranges,
+			rangeCount(bugs, window))
+	}
+	defaults := rollingWindows(ts.now)
+	ts.report.Bugs.Week = rangeCount(bugs, defaults[0]).ReportCount
+	ts.report.Bugs.Month = rangeCount(bugs, defaults[1]).ReportCount
 
-	versions := FixVersions(openBugs)
-	securityLevels := SecurityLevels(openBugs)
+	versions := openBugs.FixVersions()
+	securityLevels := openBugs.SecurityLevels()
 	sort.Slice(versions, func(i, j int) bool {
 		return strings.Compare(versions[i], versions[j]) > 0
 	})
@@ -167,16 +502,21 @@ func (ts *TicketStats) bugs() {
 	ts.report.Bugs.BugCounts.Versions = versions
 
 	for _, security := range securityLevels {
-		sbs := FilterBySecurityLevel(openBugs, security)
+		sbs := NewIssueSet(openBugs.BySecurityLevel(security))
 		values := make([]string, 0)
 		values = append(values, security)
+		row := BugCountRow{
+			Security: security,
+			Counts:   make([]int, 0),
+		}
 		sum := 0
 		for _, version := range versions {
-			bs := FilterByFixVersion(sbs, version)
+			bs := sbs.ByFixVersion(version)
 
 			stat := NewReportBugStats()
 			stat.Count = len(bs)
 			sum += stat.Count
+			row.Counts = append(row.Counts, stat.Count)
 
 			if stat.Count > 0 {
 				values = append(values, fmt.Sprintf("%d", stat.Count))
@@ -187,8 +527,8 @@ func (ts *TicketStats) bugs() {
 				OrderByStatus(bs)
 				OrderByPriority(bs)
 				for _, b := range bs {
-					stat.Bugs = append(stat.Bugs,
-						b.ToReportIssue(ts.jiraBase, ts.config))
+					stat.Bugs = append(stat.Bugs, b.ToReportIssue(
+						ts.jiraBase, ts.now, ts.config, ts.calendar))
 				}
 
 				ts.report.Bugs.BugStats = append(ts.report.Bugs.BugStats, stat)
@@ -198,18 +538,21 @@ func (ts *TicketStats) bugs() {
 		}
 		values = append(values, fmt.Sprintf("%d", sum))
 		ts.report.Bugs.BugCounts.Values = append(ts.report.Bugs.BugCounts.Values, values)
+		row.Sum = sum
+		ts.report.Bugs.BugCounts.Levels = append(ts.report.Bugs.BugCounts.Levels, row)
 	}
 }
 
 // features generates the feature report data.
 func (ts *TicketStats) features() {
-	features := FilterByType(ts.issues, ts.config.Types.Feature)
+	features := ts.sectionSet(SectionFeatures).ByType(ts.config.Types.Feature)
 	openFeatures := OpenTickets(features, ts.config)
 	OrderByDue(openFeatures)
 	cluster := Clusters(openFeatures, false)
 
 	for _, feature := range cluster {
-		rf := feature.ToReportIssue(ts.jiraBase, ts.config)
+		rf := feature.ToReportIssue(ts.jiraBase, ts.now, ts.config,
+			ts.calendar)
 		if len(rf.Parents) == 0 {
 			ts.report.Features = append(ts.report.Features, rf)
 		}
@@ -218,12 +561,14 @@ func (ts *TicketStats) features() {
 
 // improvements generates the improvement report data.
 func (ts *TicketStats) improvements() {
-	improvements := FilterByType(ts.issues, ts.config.Types.Improvement)
+	improvements := ts.sectionSet(SectionImprovements).ByType(
+		ts.config.Types.Improvement)
 	openImprovements := OpenTickets(improvements, ts.config)
 	OrderByDue(openImprovements)
 
 	for _, improvement := range Clusters(openImprovements, false) {
-		ri := improvement.ToReportIssue(ts.jiraBase, ts.config)
+		ri := improvement.ToReportIssue(ts.jiraBase, ts.now, ts.config,
+			ts.calendar)
 		if len(ri.Parents) == 0 {
 			ts.report.Improvements = append(ts.report.Improvements, ri)
 		}
@@ -232,153 +577,429 @@ func (ts *TicketStats) improvements() {
 
 // other generates the other issue report data.
 func (ts *TicketStats) other() {
-	others := Filter(ts.issues, func(issue *Issue) bool {
-		return !(issue.Type == ts.config.Types.Bug ||
-			issue.Type == ts.config.Types.Feature ||
-			issue.Type == ts.config.Types.Improvement)
-	})
+	others := ts.sectionSet(SectionOther).Where(Not(Or(
+		ByType(ts.config.Types.Bug), ByType(ts.config.Types.Feature),
+		ByType(ts.config.Types.Improvement))))
 
-	ts.report.Other.Count = len(OpenTickets(others, ts.config))
+	ts.report.Other.Count = len(OpenTickets(others.Issues(), ts.config))
 
-	for _, t := range Types(others) {
-		issues := FilterByType(others, t)
-		count := len(OpenTickets(issues, ts.config))
-
-		statWeek := OtherTypeStats{
-			Count: count,
-			Type:  t,
-		}
-		statWeek.Report.Created = len(CreatedLastWeek(issues))
-		statWeek.Report.Resolved = len(ClosedLastWeek(issues))
-		statWeek.Report.Diff = statWeek.Report.Created - statWeek.Report.Resolved
-
-		ts.report.Other.Week = append(ts.report.Other.Week, statWeek)
-
-		statMonth := OtherTypeStats{
-			Count: count,
-			Type:  t,
+	types := others.Types()
+	typeIssues := make(map[string]*IssueSet)
+	openCounts := make(map[string]int)
+	for _, t := range types {
+		typeIssues[t] = NewIssueSet(others.ByType(t))
+		openCounts[t] = len(OpenTickets(typeIssues[t].Issues(), ts.config))
+	}
+	typeStats := func(window Window) []OtherTypeStats {
+		stats := make([]OtherTypeStats, 0)
+		for _, t := range types {
+			stats = append(stats, OtherTypeStats{
+				Count:  openCounts[t],
+				Type:   t,
+				Report: rangeCount(typeIssues[t], window).ReportCount,
+			})
 		}
-		statMonth.Report.Created = len(CreatedLastMonth(issues))
-		statMonth.Report.Resolved = len(ClosedLastMonth(issues))
-		statMonth.Report.Diff = statWeek.Report.Created - statWeek.Report.Resolved
-
-		ts.report.Other.Month = append(ts.report.Other.Month, statMonth)
+		return stats
+	}
+	for _, window := range ts.windows {
+		ts.report.Other.Ranges = append(ts.report.Other.Ranges, OtherRange{
+			TimeRange: window.Name,
+			Types:     typeStats(window),
+		})
 	}
+	defaults := rollingWindows(ts.now)
+	ts.report.Other.Week = typeStats(defaults[0])
+	ts.report.Other.Month = typeStats(defaults[1])
+}
+
+// rangeCount counts the tickets created and resolved within the window.
+func rangeCount(issues *IssueSet, window Window) ReportRangeCount {
+	count := ReportRangeCount{TimeRange: window.Name}
+	count.Created = len(issues.CreatedIn(window))
+	count.Resolved = len(issues.ClosedIn(window))
+	count.Diff = count.Created - count.Resolved
+	return count
 }
 
 // resources generates the work effort report data.
 func (ts *TicketStats) resources() {
-	ranges := []string{"Last week", "Last month", "Last quarter", "Last year"}
-	hours := calcHours(ts.issues)
-	fte := calcFTE(hours)
+	set := ts.sectionSet(SectionResources)
+	issues := set.Issues()
+	ranges := make([]string, 0)
+	for _, window := range ts.windows {
+		ranges = append(ranges, window.Name)
+	}
+	hours := calcHours(issues, ts.windows)
+	fte := calcFTE(hours, ts.windows, ts.calendar)
 
 	for i, r := range ranges {
 		ts.report.Resources.Spend = append(ts.report.Resources.Spend, ResourceSpend{
 			TimeRange: r,
-			Effort:    formatWork(hours[i]),
+			Effort:    ts.calendar.FormatWork(hours[i]),
+			Hours:     hours[i],
 			FTE:       fmt.Sprintf("%.2f", fte[i]),
+			FTEValue:  fte[i],
 		})
 	}
 
-	groups := make([]ResourceGroup, 4)
-	for _, g := range groups {
-		g.Type = "Type"
+	ts.report.Resources.Usage = append(ts.report.Resources.Usage,
+		ts.usage("Type", ranges, hours, 3, set.Types(), set.ByType))
+
+	ts.report.Resources.Usage = append(ts.report.Resources.Usage,
+		ts.usage("Label", ranges, hours, 5, set.Labels(), set.ByLabel))
+
+	for _, field := range ts.config.Customs.Groups {
+		values := FieldValues(issues, field, ts.config)
+		ts.report.Resources.Usage = append(ts.report.Resources.Usage,
+			ts.usage(field, ranges, hours, 5, values, func(v string) []*Issue {
+				return FilterByField(issues, field, v, ts.config)
+			}))
 	}
-	types := Types(ts.issues)
-	sort.Slice(types, func(i, j int) bool {
-		return strings.Compare(types[i], types[j]) < 0
-	})
-	for _, t := range types {
-		issuesByType := FilterByType(ts.issues, t)
 
-		ghours := calcHours(issuesByType)
-		gfte := calcFTE(ghours)
+	times := ResolutionTimesIn(issues, ts.windows)
+	resolvedTypes := make([]string, 0)
+	for issueType := range times {
+		resolvedTypes = append(resolvedTypes, issueType)
+	}
+	sort.Strings(resolvedTypes)
+	for i, window := range ts.windows {
+		average := NewResourceAverage()
+		average.TimeRange = window.Name
+		for _, issueType := range resolvedTypes {
+			stats := times[issueType][i]
+			average.Details = append(average.Details, ResourceAverageDetails{
+				Type:        issueType,
+				Count:       stats.Count,
+				Median:      ts.calendar.FormatWork(stats.Median),
+				MedianHours: stats.Median,
+				Mean:        ts.calendar.FormatWork(stats.Mean),
+				MeanHours:   stats.Mean,
+			})
+		}
+		ts.report.Resources.Average = append(ts.report.Resources.Average,
+			average)
+	}
+}
+
+// flow generates the lead time report data.
+func (ts *TicketStats) flow() {
+	issues := ts.section(SectionFlow, ts.issues)
+	groups := []struct {
+		name  string
+		times func(issues []*Issue, windows []Window) map[string][]FlowStats
+	}{
+		{"Type", LeadTimesByType},
+		{"Priority", LeadTimesByPriority},
+		{"Component", LeadTimesByComponent},
+	}
 
-		for i, g := range groups {
-			percent := int((ghours[i] / hours[i]) * 100.0)
-			if percent < 3 {
-				continue
+	for _, group := range groups {
+		times := group.times(issues, ts.windows)
+		names := make([]string, 0)
+		for name := range times {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		flows := make([]ResourceFlow, 0)
+		for i, window := range ts.windows {
+			flow := NewResourceFlow()
+			flow.TimeRange = window.Name
+			flow.Group = group.name
+			for _, name := range names {
+				stats := times[name][i]
+				if stats.Count == 0 {
+					continue
+				}
+				flow.Details = append(flow.Details, ResourceFlowDetails{
+					Name:       name,
+					Count:      stats.Count,
+					Mean:       formatDays(stats.Mean),
+					MeanDays:   stats.Mean,
+					Median:     formatDays(stats.Median),
+					MedianDays: stats.Median,
+					P85:        formatDays(stats.P85),
+					P85Days:    stats.P85,
+					P95:        formatDays(stats.P95),
+					P95Days:    stats.P95,
+				})
 			}
+			flows = append(flows, flow)
+		}
+		ts.report.Resources.Flow = append(ts.report.Resources.Flow, flows)
+	}
+}
 
-			g.Details = append(g.Details, ResourceDetails{
-				Type:    t,
-				Work:    formatWork(ghours[i]),
-				FTE:     fmt.Sprintf("%.2f", gfte[i]),
-				Percent: percent,
+// statuses generates the time in status report data of bugs and features.
+// Only tickets with changelog are considered, the section is skipped if no
+// ticket has a changelog.
+func (ts *TicketStats) statuses() {
+	issues := ts.section(SectionStatuses, ts.issues)
+	withHistory := Filter(issues, func(issue *Issue) bool {
+		return len(issue.History) > 0
+	})
+	if len(withHistory) == 0 {
+		return
+	}
+	ts.report.HasStatuses = true
+
+	for _, issueType := range []string{ts.config.Types.Bug,
+		ts.config.Types.Feature} {
+		issues := FilterByType(withHistory, issueType)
+		if len(issues) == 0 {
+			continue
+		}
+
+		rtype := NewReportStatusType()
+		rtype.Type = issueType
+		rtype.Count = len(issues)
+		efficiency, count := MeanFlowEfficiency(issues, ts.now, ts.config)
+		if count > 0 {
+			rtype.HasFlowEfficiency = true
+			rtype.FlowEfficiency = int(efficiency*100.0 + 0.5)
+		}
+		for _, st := range StatusTimes(issues, ts.now, ts.config) {
+			rtype.Statuses = append(rtype.Statuses, ReportStatusTime{
+				Status:     st.Status,
+				Category:   st.Category,
+				Count:      st.Count,
+				Mean:       formatDays(st.Mean),
+				MeanDays:   st.Mean,
+				Median:     formatDays(st.Median),
+				MedianDays: st.Median,
 			})
-			groups[i] = g
 		}
+		ts.report.Statuses.Types = append(ts.report.Statuses.Types, rtype)
 	}
-	ts.report.Resources.Usage = append(ts.report.Resources.Usage, groups)
 
-	groups = make([]ResourceGroup, 4)
-	for _, g := range groups {
-		g.Type = "Label"
+	reopened := Reopened(withHistory, ts.now, ts.config)
+	OrderByCreated(reopened)
+	for _, issue := range reopened {
+		ts.report.Statuses.Reopened = append(ts.report.Statuses.Reopened,
+			issue.ToReportIssue(ts.jiraBase, ts.now, ts.config,
+				ts.calendar))
 	}
-	labels := Labels(ts.issues)
-	sort.Slice(labels, func(i, j int) bool {
-		return strings.Compare(labels[i], labels[j]) < 0
-	})
-	for _, l := range labels {
-		issuesByType := FilterByLabel(ts.issues, l)
+}
+
+// cumulativeFlow generates the cumulative flow data of all ticket types for
+// the configured number of days.
+func (ts *TicketStats) cumulativeFlow() {
+	issues := ts.section(SectionCumulativeFlow, ts.issues)
+	days := ts.config.CumulativeFlow.Days
+	if days <= 0 {
+		return
+	}
+
+	flows := CumulativeFlowByType(issues, ts.now, days, ts.config)
+	if len(flows) == 0 {
+		return
+	}
+	ts.report.HasCumulativeFlow = true
+
+	types := make([]string, 0)
+	for issueType := range flows {
+		types = append(types, issueType)
+	}
+	sort.Strings(types)
+
+	for i, issueType := range types {
+		series := ReportFlowSeries{
+			Type:       issueType,
+			Categories: make([]ReportFlowCategory, 0),
+		}
+		for _, category := range categories {
+			counts := make([]int, 0)
+			for _, day := range flows[issueType] {
+				counts = append(counts, day.Counts[category])
+			}
+			series.Categories = append(series.Categories, ReportFlowCategory{
+				Category: category,
+				Counts:   counts,
+			})
+		}
+		ts.report.CumulativeFlow.Types = append(
+			ts.report.CumulativeFlow.Types, series)
+
+		if i > 0 {
+			continue
+		}
+		for _, day := range flows[issueType] {
+			ts.report.CumulativeFlow.Times = append(
+				ts.report.CumulativeFlow.Times, day.Date)
+			ts.report.CumulativeFlow.Dates = append(
+				ts.report.CumulativeFlow.Dates,
+				day.Date.Format(ts.config.Formats.Date))
+		}
+	}
+}
 
-		ghours := calcHours(issuesByType)
-		gfte := calcFTE(ghours)
+// usage generates a resource usage row, i.e. the effort spend on the
+// issues of each name for all time ranges. Names with less than
+// minPercent of the total effort are skipped.
+func (ts *TicketStats) usage(groupType string, ranges []string, hours []Work,
+	minPercent int, names []string,
+	issues func(name string) []*Issue) []ResourceGroup {
+	groups := newResourceGroups(groupType, ranges)
+
+	sort.Slice(names, func(i, j int) bool {
+		return strings.Compare(names[i], names[j]) < 0
+	})
+	for _, name := range names {
+		ghours := calcHours(issues(name), ts.windows)
+		gfte := calcFTE(ghours, ts.windows, ts.calendar)
 
 		for i, g := range groups {
 			percent := int((ghours[i] / hours[i]) * 100.0)
-			if percent < 5 {
+			if percent < minPercent {
 				continue
 			}
+
 			g.Details = append(g.Details, ResourceDetails{
-				Type:    l,
-				Work:    formatWork(ghours[i]),
-				FTE:     fmt.Sprintf("%.2f", gfte[i]),
-				Percent: percent,
+				Type:     name,
+				Work:     ts.calendar.FormatWork(ghours[i]),
+				Hours:    ghours[i],
+				FTE:      fmt.Sprintf("%.2f", gfte[i]),
+				FTEValue: gfte[i],
+				Percent:  percent,
 			})
 			groups[i] = g
 		}
 	}
-	ts.report.Resources.Usage = append(ts.report.Resources.Usage, groups)
-
-	averageQuarter := NewResourceAverage()
-	averageQuarter.TimeRange = "Last quarter"
-	averageYear := NewResourceAverage()
-	averageYear.TimeRange = "Last year"
-	for issueType, times := range ResultionTimesByType(ClosedLastYear(ts.issues)) {
-		averageQuarter.Details = append(averageQuarter.Details, ResourceAverageDetails{
-			Type:   issueType,
-			Count:  times.Quarter.Count,
-			Median: formatWork(times.Quarter.Median),
-			Mean:   formatWork(times.Quarter.Mean),
-		})
 
-		averageYear.Details = append(averageYear.Details, ResourceAverageDetails{
-			Type:   issueType,
-			Count:  times.Year.Count,
-			Median: formatWork(times.Year.Median),
-			Mean:   formatWork(times.Year.Mean),
+	return groups
+}
+
+// people generates the per person effort data for the last week, month and
+// quarter, and the days of the last quarter with more than the configured
+// daily maximum of booked hours.
+func (ts *TicketStats) people() {
+	set := ts.sectionSet(SectionPeople)
+	if len(ts.windows) == 0 {
+		return
+	}
+	// people and overbookings are reported for the last quarter, independent
+	// of the configured windows
+	quarter := ts.now.AddDate(0, -3, 0)
+
+	authors := set.Authors()
+	sort.Strings(authors)
+	for _, author := range authors {
+		issues := set.ByAuthor(author)
+		if WorkByAuthorBetween(issues, author, quarter, ts.now) == 0 {
+			continue
+		}
+
+		person := NewResourcePerson()
+		person.Name = author
+
+		hours := make([]Work, 0)
+		for _, window := range ts.windows {
+			hours = append(hours, WorkByAuthorBetween(issues, author,
+				window.Start, window.End))
+		}
+		fte := calcFTE(hours, ts.windows, ts.calendar)
+
+		for i, window := range ts.windows {
+			activities := WorkByAuthor(issues, author, window.Start,
+				window.End,
+				func(issue *Issue, log WorkLog) string {
+					if log.Activity == "" {
+						return "No activity"
+					}
+					return log.Activity
+				})
+			types := WorkByAuthor(issues, author, window.Start, window.End,
+				func(issue *Issue, log WorkLog) string {
+					return issue.Type
+				})
+
+			person.Ranges = append(person.Ranges, ResourcePersonRange{
+				TimeRange: window.Name,
+				Effort:    ts.calendar.FormatWork(hours[i]),
+				Hours:     hours[i],
+				FTE:       fmt.Sprintf("%.2f", fte[i]),
+				FTEValue:  fte[i],
+				Activities: resourceDetails(activities, hours[i], fte[i],
+					ts.calendar),
+				Types: resourceDetails(types, hours[i], fte[i], ts.calendar),
+			})
+		}
+
+		for _, day := range Overbookings(issues, author, quarter, ts.now,
+			ts.config.DailyMaximum()) {
+			person.Overbookings = append(person.Overbookings,
+				ResourceOverbooking{
+					Date:      day.Date.Format(ts.config.Formats.Date),
+					DateValue: day.Date,
+					Effort:    ts.calendar.FormatWork(day.Hours),
+					Hours:     day.Hours,
+				})
+		}
+		person.Overbooked = len(person.Overbookings) > 0
+
+		ts.report.Resources.People = append(ts.report.Resources.People,
+			person)
+	}
+}
+
+// resourceDetails converts the work grouped by name to ResourceDetails
+// sorted by name. The percentage and FTE are relative to the given total.
+func resourceDetails(work map[string]Work, total Work, fte float64,
+	calendar *Calendar) []ResourceDetails {
+	names := make([]string, 0)
+	for name := range work {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	details := make([]ResourceDetails, 0)
+	for _, name := range names {
+		var percent int
+		var nfte float64
+		if total > 0 {
+			percent = int((work[name] / total) * 100.0)
+			nfte = fte * float64(work[name]/total)
+		}
+		details = append(details, ResourceDetails{
+			Type:     name,
+			Work:     calendar.FormatWork(work[name]),
+			Hours:    work[name],
+			FTE:      fmt.Sprintf("%.2f", nfte),
+			FTEValue: nfte,
+			Percent:  percent,
 		})
 	}
-	ts.report.Resources.Average = append(ts.report.Resources.Average, averageQuarter, averageYear)
+	return details
+}
+
+// newResourceGroups creates a ResourceGroup for each time range.
+func newResourceGroups(groupType string, ranges []string) []ResourceGroup {
+	groups := make([]ResourceGroup, 0)
+	for _, r := range ranges {
+		group := NewResourceGroup()
+		group.Type = groupType
+		group.TimeRange = r
+		groups = append(groups, group)
+	}
+	return groups
 }
 
-// calcHours calculates the work hours spend for the given tickets.
-func calcHours(issues []*Issue) []Work {
+// calcHours calculates the work hours spend for the given tickets within
+// each of the windows.
+func calcHours(issues []*Issue, windows []Window) []Work {
 	hours := make([]Work, 0)
-	hours = append(hours, WorkAfter(issues, time.Now().AddDate(0, 0, -7)))
-	hours = append(hours, WorkAfter(issues, time.Now().AddDate(0, -1, 0)))
-	hours = append(hours, WorkAfter(issues, time.Now().AddDate(0, -3, 0)))
-	hours = append(hours, WorkAfter(issues, time.Now().AddDate(-1, 0, 0)))
+	for _, window := range windows {
+		hours = append(hours, WorkBetween(issues, window.Start, window.End))
+	}
 	return hours
 }
 
-// calcFTE calculates the FTEs for given work hours.
-func calcFTE(hours []Work) []float64 {
+// calcFTE calculates the FTEs for given work hours of the windows, based on
+// the capacity of the working calendar within the windows.
+func calcFTE(hours []Work, windows []Window, calendar *Calendar) []float64 {
 	fte := make([]float64, 0)
-	fte = append(fte, float64(hours[0]/40.0))
-	fte = append(fte, float64(hours[1]/(40.0*4.25)))
-	fte = append(fte, float64(hours[2]/(40.0*4.25*3)))
-	fte = append(fte, float64(hours[3]/(40.0*52)))
+	for i, window := range windows {
+		fte = append(fte, float64(hours[i]/calendar.Capacity(window)))
+	}
 	return fte
 }
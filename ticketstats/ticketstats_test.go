@@ -1,37 +1,48 @@
 package ticketstats
 
 import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
 
+// testNow is the reference time used by the tests.
+var testNow = time.Date(2021, 11, 15, 12, 0, 0, 0, time.UTC)
+
+// testCalendar is the default working calendar used by the tests.
+var testCalendar, _ = NewCalendar(DefaultConfig())
+
 func TestCalcHours(t *testing.T) {
 	issues := make([]*Issue, 0)
 
 	issue := NewIssue()
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Hours:    10.0,
-		Date:     time.Now().AddDate(0, 0, -2),
+		Date:     testNow.AddDate(0, 0, -2),
 		Activity: "123456",
 	})
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Hours:    20.0,
-		Date:     time.Now().AddDate(0, 0, -10),
+		Date:     testNow.AddDate(0, 0, -10),
 		Activity: "123456",
 	})
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Hours:    30.0,
-		Date:     time.Now().AddDate(0, -1, 0),
+		Date:     testNow.AddDate(0, -1, 0),
 		Activity: "123456",
 	})
 	issue.LogWorks = append(issue.LogWorks, WorkLog{
 		Hours:    40.0,
-		Date:     time.Now().AddDate(0, -6, 0),
+		Date:     testNow.AddDate(0, -6, 0),
 		Activity: "123456",
 	})
 	issues = append(issues, issue)
 
-	work := calcHours(issues)
+	work := calcHours(issues, DefaultConfig().TimeWindows(testNow))
 
 	if work[0] != 10.0 ||
 		work[1] != 30.0 ||
@@ -42,8 +53,10 @@ func TestCalcHours(t *testing.T) {
 }
 
 func TestCalcFTE(t *testing.T) {
-	work := []Work{80.0, 170.0, 510.0, 1040.0}
-	fte := calcFTE(work)
+	// the working days of the windows are 5, 21, 65.5 and 260.5
+	work := []Work{80.0, 168.0, 524.0, 1042.0}
+	fte := calcFTE(work, DefaultConfig().TimeWindows(testNow),
+		testCalendar)
 	if fte[0] != 2.0 ||
 		fte[1] != 1.0 ||
 		fte[2] != 1.0 ||
@@ -51,3 +64,282 @@ func TestCalcFTE(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestEvaluateSourceSplitByComponent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the reports are written to the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	issue := func(key string, component string) *Issue {
+		issue := NewIssue()
+		issue.Key = key
+		issue.Type = "Bug"
+		issue.Components = append(issue.Components, component)
+		return issue
+	}
+	source := testSource{
+		issue("PRJ-1", "A"),
+		issue("PRJ-2", "B"),
+		issue("PRJ-3", "B"),
+	}
+
+	// the history is not part of the default config
+	config := DefaultConfig()
+	config.History = filepath.Join(dir, "history")
+
+	err = EvaluateSource(source, config, Options{
+		AsOf:             testNow,
+		SplitByComponent: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewSnapshotStore(config.History)
+	for component, count := range map[string]int{"": 3, "A": 1, "B": 2} {
+		snapshots, err := store.Load(component, testNow.Add(time.Second), 1)
+		if err != nil || len(snapshots) != 1 {
+			log.Println("TEST: no report for component", component, err)
+			t.Fail()
+			continue
+		}
+		if len(snapshots[0].Issues) != count {
+			log.Println("TEST: wrong issues for component", component,
+				len(snapshots[0].Issues))
+			t.Fail()
+		}
+	}
+}
+
+func TestParseFormats(t *testing.T) {
+	formats, err := parseFormats("")
+	if err != nil || len(formats) != 1 || formats[0] != FormatHtml {
+		t.Fail()
+	}
+
+	formats, err = parseFormats("html, JSON")
+	if err != nil || len(formats) != 2 || formats[1] != FormatJson {
+		t.Fail()
+	}
+
+	_, err = parseFormats("pdf")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+// testSource is an IssueSource returning a fixed issue list.
+type testSource []*Issue
+
+func (source testSource) Issues() ([]*Issue, error) {
+	return source, nil
+}
+
+func TestPeople(t *testing.T) {
+	issue := NewIssue()
+	issue.Key = "PRJ-1"
+	issue.Type = "Bug"
+	issue.LogWorks = append(issue.LogWorks,
+		WorkLog{Hours: 12, Date: testNow.AddDate(0, 0, -1), Author: "a",
+			Activity: "dev"},
+		WorkLog{Hours: 4, Date: testNow.AddDate(0, 0, -20), Author: "a",
+			Activity: "test"},
+		WorkLog{Hours: 8, Date: testNow.AddDate(0, -6, 0), Author: "b"})
+
+	report, err := GenerateReport([]*Issue{issue}, "", DefaultConfig(),
+		Options{AsOf: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// b has no bookings in the last quarter
+	people := report.Resources.People
+	if len(people) != 1 || people[0].Name != "a" {
+		log.Println("TEST: wrong people", len(people))
+		t.FailNow()
+	}
+
+	person := people[0]
+	if len(person.Ranges) != 4 || person.Ranges[0].Hours != 12 ||
+		person.Ranges[1].Hours != 16 || person.Ranges[3].Hours != 16 {
+		log.Println("TEST: wrong ranges", person.Ranges)
+		t.Fail()
+	}
+	activities := person.Ranges[1].Activities
+	if len(activities) != 2 || activities[0].Type != "dev" ||
+		activities[0].Percent != 75 {
+		log.Println("TEST: wrong activities", activities)
+		t.Fail()
+	}
+	types := person.Ranges[1].Types
+	if len(types) != 1 || types[0].Type != "Bug" || types[0].Percent != 100 {
+		log.Println("TEST: wrong types", types)
+		t.Fail()
+	}
+	if !person.Overbooked || len(person.Overbookings) != 1 ||
+		person.Overbookings[0].Hours != 12 {
+		log.Println("TEST: overbooking not detected", person.Overbookings)
+		t.Fail()
+	}
+}
+
+func TestPeopleWithoutEfforts(t *testing.T) {
+	issue := NewIssue()
+	issue.Key = "PRJ-1"
+	issue.LogWorks = append(issue.LogWorks,
+		WorkLog{Hours: 8, Date: testNow.AddDate(0, 0, -1), Author: "a"},
+		WorkLog{Hours: 11, Date: testNow.AddDate(0, 0, -2), Author: "a"})
+
+	// a config file without Efforts
+	config := DefaultConfig()
+	config.Efforts = ConfigEfforts{}
+	report, err := GenerateReport([]*Issue{issue}, "", config,
+		Options{AsOf: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	people := report.Resources.People
+	if len(people) != 1 || len(people[0].Overbookings) != 1 ||
+		people[0].Overbookings[0].Hours != 11 {
+		log.Println("TEST: wrong overbookings without maximum", people)
+		t.Fail()
+	}
+}
+
+func TestLoadIssues(t *testing.T) {
+	issue := func(key string, component string) *Issue {
+		issue := NewIssue()
+		issue.Key = key
+		issue.Components = append(issue.Components, component)
+		return issue
+	}
+	source := testSource{
+		issue("PRJ-1", "A"),
+		issue("PRJ-2", "B"),
+		issue("OTHER-1", "A"),
+	}
+	config := DefaultConfig()
+
+	issues, err := LoadIssues(source, config, Options{Project: "PRJ"})
+	if err != nil || len(issues) != 2 {
+		log.Println("TEST: wrong project filter", len(issues), err)
+		t.Fail()
+	}
+
+	config.Component = "A"
+	issues, err = LoadIssues(source, config, Options{Project: "PRJ"})
+	if err != nil || len(issues) != 1 || issues[0].Key != "PRJ-1" {
+		log.Println("TEST: wrong component filter", len(issues), err)
+		t.Fail()
+	}
+
+	report, err := GenerateReport(issues, "A", config, Options{AsOf: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Component != "A" || !report.AsOf.Equal(testNow) ||
+		report.Date != "2021-11-15" {
+		log.Println("TEST: wrong report", report.Component, report.Date)
+		t.Fail()
+	}
+}
+
+func TestRun(t *testing.T) {
+	f, err := os.Open("../example.data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	config := DefaultConfig()
+	result, err := Run(context.Background(), Options{
+		Input:            f,
+		Config:           &config,
+		AsOf:             testNow,
+		SplitByComponent: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Issues) == 0 || len(result.Reports) != 2 {
+		log.Println("TEST: wrong result", len(result.Issues),
+			len(result.Reports))
+		t.Fail()
+	}
+	report, ok := result.Report("Module A")
+	if !ok || report.Bugs.Count == 0 || !report.AsOf.Equal(testNow) {
+		log.Println("TEST: wrong component report")
+		t.Fail()
+	}
+	_, err = os.Stat("report_Module A.html")
+	if !os.IsNotExist(err) {
+		log.Println("TEST: report written without output dir")
+		t.Fail()
+	}
+}
+
+func TestRunOutputDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "reports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = Run(context.Background(), Options{
+		Source:    testSource{NewIssue()},
+		AsOf:      testNow,
+		Format:    "html,json,csv",
+		OutputDir: dir,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"report_.html", "report_.json",
+		"report_.csv"} {
+		_, err = os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			log.Println("TEST: report not written", name, err)
+			t.Fail()
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	_, err := Run(context.Background(), Options{})
+	if err == nil {
+		log.Println("TEST: missing source without error")
+		t.Fail()
+	}
+
+	_, err = Run(context.Background(), Options{
+		Source: testSource{},
+		Format: "pdf",
+	})
+	if err == nil {
+		log.Println("TEST: invalid format without error")
+		t.Fail()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Run(ctx, Options{Source: testSource{}})
+	if err != context.Canceled {
+		log.Println("TEST: canceled context", err)
+		t.Fail()
+	}
+}
@@ -0,0 +1,162 @@
+package ticketstats
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window kinds of ConfigWindow.
+const (
+	// the window ends with the reference time, e.g. the last 7 days
+	WindowRolling = "rolling"
+	// the window is the calendar period containing the reference time, e.g.
+	// the current ISO week, up to the reference time
+	WindowCalendar = "calendar"
+)
+
+// Window is a time range of the statistics. Dates after Start and not after
+// End are within the window.
+type Window struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// Window.Contains tests if the date is within the window.
+func (window Window) Contains(date time.Time) bool {
+	return date.After(window.Start) && !date.After(window.End)
+}
+
+// parseWindowLength splits a window length like "7d" or "3m" into number
+// and unit.
+func parseWindowLength(length string) (int, string, error) {
+	length = strings.TrimSpace(length)
+	if len(length) < 2 {
+		return 0, "", fmt.Errorf("invalid window length %q", length)
+	}
+	unit := length[len(length)-1:]
+	if !strings.Contains("dwmqy", unit) {
+		return 0, "", fmt.Errorf("invalid window unit of %q", length)
+	}
+	n, err := strconv.Atoi(length[:len(length)-1])
+	if err != nil || n < 1 {
+		return 0, "", fmt.Errorf("invalid window length %q", length)
+	}
+	return n, unit, nil
+}
+
+// addUnits moves the date by n units of the window length.
+func addUnits(date time.Time, unit string, n int) time.Time {
+	switch unit {
+	case "d":
+		return date.AddDate(0, 0, n)
+	case "w":
+		return date.AddDate(0, 0, 7*n)
+	case "m":
+		return date.AddDate(0, n, 0)
+	case "q":
+		return date.AddDate(0, 3*n, 0)
+	}
+	return date.AddDate(n, 0, 0)
+}
+
+// periodStart returns the start of the calendar period of the unit
+// containing the date. Weeks are ISO weeks starting on Monday, years are
+// fiscal years starting with the given month.
+func periodStart(date time.Time, unit string, fiscalYearStart int) time.Time {
+	year, month, day := date.Date()
+	location := date.Location()
+
+	switch unit {
+	case "d":
+		return time.Date(year, month, day, 0, 0, 0, 0, location)
+	case "w":
+		weekday := (int(date.Weekday()) + 6) % 7
+		return time.Date(year, month, day-weekday, 0, 0, 0, 0, location)
+	case "m":
+		return time.Date(year, month, 1, 0, 0, 0, 0, location)
+	case "q":
+		return time.Date(year, (month-1)/3*3+1, 1, 0, 0, 0, 0, location)
+	}
+
+	if fiscalYearStart < 1 || fiscalYearStart > 12 {
+		fiscalYearStart = 1
+	}
+	if int(month) < fiscalYearStart {
+		year--
+	}
+	return time.Date(year, time.Month(fiscalYearStart), 1, 0, 0, 0, 0,
+		location)
+}
+
+// NewWindow creates the time window of the configuration ending with now.
+// A calendar window of length n covers the current period and the n-1
+// periods before.
+func NewWindow(cw ConfigWindow, now time.Time,
+	fiscalYearStart int) (Window, error) {
+	n, unit, err := parseWindowLength(cw.Length)
+	if err != nil {
+		return Window{}, err
+	}
+
+	window := Window{
+		Name: cw.Name,
+		End:  now,
+	}
+	if window.Name == "" {
+		window.Name = cw.Length
+	}
+
+	switch cw.Kind {
+	case WindowRolling, "":
+		window.Start = addUnits(now, unit, -n)
+	case WindowCalendar:
+		start := addUnits(periodStart(now, unit, fiscalYearStart), unit, 1-n)
+		// the start of the period is within the window
+		window.Start = start.Add(-time.Nanosecond)
+	default:
+		return Window{}, fmt.Errorf("invalid window kind %q", cw.Kind)
+	}
+
+	return window, nil
+}
+
+// Config.TimeWindows returns the configured time windows ending with now.
+// Invalid windows are logged and skipped. Without configured windows, e.g.
+// of an older config file, the default windows are used.
+func (config Config) TimeWindows(now time.Time) []Window {
+	ranges := config.Windows.Ranges
+	if len(ranges) == 0 {
+		ranges = DefaultConfig().Windows.Ranges
+	}
+
+	windows := make([]Window, 0)
+	for _, cw := range ranges {
+		window, err := NewWindow(cw, now, config.Windows.FiscalYearStart)
+		if err != nil {
+			log.Println("ERROR: window", cw.Name+":", err)
+			continue
+		}
+		windows = append(windows, window)
+	}
+	return windows
+}
+
+// rollingWindows returns the default windows, the last week, month, quarter
+// and year ending with now.
+func rollingWindows(now time.Time) []Window {
+	return DefaultConfig().TimeWindows(now)
+}
+
+// CreatedIn returns all issues created within the window.
+func CreatedIn(issues []*Issue, window Window) []*Issue {
+	return Filter(issues, CreatedWithin(window))
+}
+
+// ClosedIn returns all issues resolved within the window.
+func ClosedIn(issues []*Issue, window Window) []*Issue {
+	return Filter(issues, ClosedWithin(window))
+}
@@ -0,0 +1,112 @@
+package ticketstats
+
+import (
+	"log"
+	"testing"
+	"time"
+)
+
+func TestRollingWindows(t *testing.T) {
+	windows := DefaultConfig().TimeWindows(testNow)
+	if len(windows) != 4 || windows[0].Name != "Last week" ||
+		!windows[0].Start.Equal(testNow.AddDate(0, 0, -7)) ||
+		!windows[3].Start.Equal(testNow.AddDate(-1, 0, 0)) {
+		log.Println("TEST: wrong default windows", windows)
+		t.FailNow()
+	}
+
+	// the start is not within the window
+	if windows[0].Contains(testNow.AddDate(0, 0, -7)) ||
+		!windows[0].Contains(testNow) ||
+		windows[0].Contains(testNow.Add(time.Second)) {
+		log.Println("TEST: wrong window bounds")
+		t.Fail()
+	}
+}
+
+func TestCalendarWindows(t *testing.T) {
+	config := DefaultConfig()
+	config.Windows.FiscalYearStart = 4
+	config.Windows.Ranges = []ConfigWindow{
+		{Name: "Week", Kind: WindowCalendar, Length: "1w"},
+		{Name: "Month", Kind: WindowCalendar, Length: "1m"},
+		{Name: "Quarter", Kind: WindowCalendar, Length: "1q"},
+		{Name: "Fiscal year", Kind: WindowCalendar, Length: "1y"},
+		{Name: "Two months", Kind: WindowCalendar, Length: "2m"},
+		{Name: "Invalid", Kind: WindowCalendar, Length: "2x"},
+		{Name: "Unknown", Kind: "sliding", Length: "2d"},
+	}
+
+	// testNow is Monday, 2021-11-15
+	windows := config.TimeWindows(testNow)
+	if len(windows) != 5 {
+		log.Println("TEST: invalid windows not skipped", len(windows))
+		t.FailNow()
+	}
+
+	starts := []time.Time{
+		time.Date(2021, 11, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for i, start := range starts {
+		if !windows[i].Contains(start) ||
+			windows[i].Contains(start.Add(-time.Second)) ||
+			!windows[i].End.Equal(testNow) {
+			log.Println("TEST: wrong window", windows[i])
+			t.Fail()
+		}
+	}
+
+	// before the fiscal year start month
+	early := time.Date(2022, 2, 10, 12, 0, 0, 0, time.UTC)
+	window, err := NewWindow(ConfigWindow{Kind: WindowCalendar,
+		Length: "1y"}, early, 4)
+	if err != nil || window.Name != "1y" ||
+		!window.Contains(time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		log.Println("TEST: wrong fiscal year", window, err)
+		t.Fail()
+	}
+}
+
+func TestReportWindows(t *testing.T) {
+	bug := NewIssue()
+	bug.Key = "PRJ-1"
+	bug.Type = "Bug"
+	bug.Created = time.Date(2021, 11, 2, 8, 0, 0, 0, time.UTC)
+	task := NewIssue()
+	task.Key = "PRJ-2"
+	task.Type = "Task"
+	task.Created = time.Date(2021, 10, 30, 8, 0, 0, 0, time.UTC)
+
+	config := DefaultConfig()
+	config.Windows.Ranges = []ConfigWindow{
+		{Name: "This week", Kind: WindowCalendar, Length: "1w"},
+		{Name: "This month", Kind: WindowCalendar, Length: "1m"},
+	}
+	report, err := GenerateReport([]*Issue{bug, task}, "", config,
+		Options{AsOf: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bugs := report.Bugs.Ranges
+	if len(bugs) != 2 || bugs[0].TimeRange != "This week" ||
+		bugs[0].Created != 0 || bugs[1].Created != 1 {
+		log.Println("TEST: wrong bug ranges", bugs)
+		t.Fail()
+	}
+	other := report.Other.Ranges
+	if len(other) != 2 || len(other[1].Types) != 1 ||
+		other[1].Types[0].Report.Created != 0 {
+		log.Println("TEST: wrong other ranges", other)
+		t.Fail()
+	}
+	if len(report.Resources.Spend) != 2 ||
+		len(report.Resources.Average) != 2 {
+		log.Println("TEST: wrong resource ranges")
+		t.Fail()
+	}
+}
@@ -0,0 +1,215 @@
+package ticketstats
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// xmlDate is the date format of the Jira XML (RSS) export.
+const xmlDate = "Mon, 2 Jan 2006 15:04:05 -0700"
+
+// xmlRss is the root element of the Jira XML (RSS) export.
+type xmlRss struct {
+	Items []xmlItem `xml:"channel>item"`
+}
+
+// xmlItem is an issue of the XML export.
+type xmlItem struct {
+	Key                  xmlIdValue       `xml:"key"`
+	Summary              string           `xml:"summary"`
+	Parent               xmlIdValue       `xml:"parent"`
+	Type                 string           `xml:"type"`
+	Status               string           `xml:"status"`
+	Priority             string           `xml:"priority"`
+	Assignee             xmlUser          `xml:"assignee"`
+	Creator              xmlUser          `xml:"creator"`
+	Created              string           `xml:"created"`
+	Updated              string           `xml:"updated"`
+	Resolved             string           `xml:"resolved"`
+	Due                  string           `xml:"due"`
+	Resolution           string           `xml:"resolution"`
+	Security             string           `xml:"security"`
+	Versions             []string         `xml:"version"`
+	FixVersions          []string         `xml:"fixVersion"`
+	Components           []string         `xml:"component"`
+	Labels               []string         `xml:"labels>label"`
+	OriginalEstimate     xmlSeconds       `xml:"timeoriginalestimate"`
+	RemainingEstimate    xmlSeconds       `xml:"timeestimate"`
+	TimeSpent            xmlSeconds       `xml:"timespent"`
+	SumOriginalEstimate  xmlSeconds       `xml:"aggregatetimeoriginalestimate"`
+	SumRemainingEstimate xmlSeconds       `xml:"aggregatetimeremainingestimate"`
+	SumTimeSpent         xmlSeconds       `xml:"aggregatetimespent"`
+	LinkTypes            []xmlLinkType    `xml:"issuelinks>issuelinktype"`
+	Worklogs             []xmlWorklog     `xml:"worklogs>worklog"`
+	CustomFields         []xmlCustomField `xml:"customfields>customfield"`
+}
+
+// xmlIdValue is an element with an id attribute, e.g. the issue key.
+type xmlIdValue struct {
+	Id    string `xml:"id,attr"`
+	Value string `xml:",chardata"`
+}
+
+// xmlUser is a user reference, the value is the display name.
+type xmlUser struct {
+	Username string `xml:"username,attr"`
+	Name     string `xml:",chardata"`
+}
+
+// xmlUser.String returns the user name, or the display name if the export
+// doesn't provide user names. Unassigned issues have the user name "-1".
+func (user xmlUser) String() string {
+	if user.Username == "-1" {
+		return ""
+	}
+	if user.Username != "" {
+		return user.Username
+	}
+	return strings.TrimSpace(user.Name)
+}
+
+// xmlSeconds is a time value, the seconds are given as attribute.
+type xmlSeconds struct {
+	Seconds int `xml:"seconds,attr"`
+}
+
+// xmlLinkType groups the links of an issue link type.
+type xmlLinkType struct {
+	Name    string   `xml:"name"`
+	Outward []string `xml:"outwardlinks>issuelink>issuekey"`
+	Inward  []string `xml:"inwardlinks>issuelink>issuekey"`
+}
+
+// xmlWorklog is a work log entry, the value is the comment.
+type xmlWorklog struct {
+	Author           string `xml:"author,attr"`
+	AuthorName       string `xml:"authorDisplayName,attr"`
+	Started          string `xml:"started,attr"`
+	TimeSpentSeconds int    `xml:"timeSpentSeconds,attr"`
+	Comment          string `xml:",chardata"`
+}
+
+// xmlCustomField is a custom field with its values.
+type xmlCustomField struct {
+	Id     string   `xml:"id,attr"`
+	Name   string   `xml:"customfieldname"`
+	Values []string `xml:"customfieldvalues>customfieldvalue"`
+}
+
+// ParseXml parses a Jira XML (RSS) export of the issue navigator.
+func ParseXml(r io.Reader, config Config) ([]*Issue, error) {
+	var rss xmlRss
+	err := xml.NewDecoder(r).Decode(&rss)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse XML: %v", err)
+	}
+
+	issues := make([]*Issue, 0)
+	for _, item := range rss.Items {
+		issues = append(issues, item.toIssue(config))
+	}
+	return issues, nil
+}
+
+// xmlItem.toIssue maps an issue of the XML export to an Issue.
+func (item xmlItem) toIssue(config Config) *Issue {
+	issue := NewIssue()
+	issue.Summary = item.Summary
+	issue.Key = strings.TrimSpace(item.Key.Value)
+	issue.Id = item.Key.Id
+	issue.Parent = item.Parent.Id
+	issue.Type = item.Type
+	issue.Status = item.Status
+	issue.Priority = item.Priority
+	issue.Assignee = item.Assignee.String()
+	issue.Creator = item.Creator.String()
+	issue.Created = issue.parseXmlDate("Created", item.Created)
+	issue.Updated = issue.parseXmlDate("Updated", item.Updated)
+	issue.Resolved = issue.parseXmlDate("Resolved", item.Resolved)
+	issue.Due = issue.parseXmlDate("Due Date", item.Due)
+	issue.AffectsVersions = append(issue.AffectsVersions, item.Versions...)
+	issue.FixVersions = append(issue.FixVersions, item.FixVersions...)
+	issue.Components = append(issue.Components, item.Components...)
+	issue.Labels = append(issue.Labels, item.Labels...)
+	issue.SecurityLevel = item.Security
+	// unresolved issues have the resolution "Unresolved" and id -1
+	if item.Resolved != "" {
+		issue.Resolution = item.Resolution
+	}
+	issue.OriginalEstimate = secondsToWork(item.OriginalEstimate.Seconds)
+	issue.RemainingEstimate = secondsToWork(item.RemainingEstimate.Seconds)
+	issue.TimeSpend = secondsToWork(item.TimeSpent.Seconds)
+	issue.SumOriginalEstimate = secondsToWork(item.SumOriginalEstimate.Seconds)
+	issue.SumRemainingEstimate = secondsToWork(
+		item.SumRemainingEstimate.Seconds)
+	issue.SumTimeSpend = secondsToWork(item.SumTimeSpent.Seconds)
+
+	for _, wl := range item.Worklogs {
+		issue.LogWorks = append(issue.LogWorks, WorkLog{
+			Hours:      secondsToWork(wl.TimeSpentSeconds),
+			Date:       issue.parseXmlDate("Log Work", wl.Started),
+			Activity:   workLogActivity(wl.Comment),
+			Author:     wl.Author,
+			AuthorName: wl.AuthorName,
+			Comment:    strings.TrimSpace(wl.Comment),
+		})
+	}
+
+	for _, linkType := range item.LinkTypes {
+		for _, key := range linkType.Outward {
+			issue.addOutwardLink(linkType.Name, key)
+		}
+		for _, key := range linkType.Inward {
+			issue.addInwardLink(linkType.Name, key)
+		}
+	}
+
+	// custom fields are identified by name, using the CSV column names
+	for _, field := range item.CustomFields {
+		name := field.Name
+		if name == "" {
+			name = field.Id
+		}
+		values := make([]string, 0)
+		for _, v := range field.Values {
+			v = strings.TrimSpace(v)
+			if v != "" {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		issue.setCustomField(customFieldPrefix+name+")",
+			strings.Join(values, ", "), config)
+	}
+
+	for _, err := range issue.DateErrors {
+		log.Println("ERROR:", err)
+	}
+
+	return issue
+}
+
+// Issue.parseXmlDate parses a date of the XML export. Unparseable dates are
+// added to DateErrors and result in the zero time.
+func (issue *Issue) parseXmlDate(column string, data string) time.Time {
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(xmlDate, data)
+	if err != nil {
+		issue.DateErrors = append(issue.DateErrors, &DateError{
+			Key:    issue.Key,
+			Column: column,
+			Value:  data,
+		})
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,158 @@
+package ticketstats
+
+import (
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseXml(t *testing.T) {
+	f, err := os.Open("testdata/jira_export.xml")
+	if err != nil {
+		log.Println("TEST: missing test data", err)
+		t.FailNow()
+	}
+	defer f.Close()
+
+	config := DefaultConfig()
+	config.Customs.Fields["Custom field (Customer)"] = FieldList
+	issues, err := ParseXml(f, config)
+	if err != nil || len(issues) != 2 {
+		log.Println("TEST: wrong issues", len(issues), err)
+		t.FailNow()
+	}
+
+	issue := issues[0]
+	if issue.Key != "PRJ-1" || issue.Id != "10001" || issue.Type != "Bug" ||
+		issue.Status != "Analysis" || issue.Assignee != "dev1" ||
+		issue.Resolution != "" || issue.CustomActivity != "123456" ||
+		issue.CustomExternalId != "EXT-42" {
+		log.Println("TEST: wrong issue", issue.ToString(config, testCalendar))
+		t.Fail()
+	}
+	if len(issue.FixVersions) != 2 || len(issue.Labels) != 2 ||
+		len(issue.Components) != 1 || issue.SecurityLevel != "Internal" {
+		log.Println("TEST: wrong lists", issue.ToString(config, testCalendar))
+		t.Fail()
+	}
+	if issue.OriginalEstimate != 8 || issue.TimeSpend != 1.5 ||
+		issue.Created.Day() != 13 || issue.Due.IsZero() {
+		log.Println("TEST: wrong work or dates", issue.ToString(config, testCalendar))
+		t.Fail()
+	}
+	if len(issue.LogWorks) != 1 || issue.LogWorks[0].Author != "dev1" ||
+		issue.LogWorks[0].AuthorName != "Developer One" ||
+		issue.LogWorks[0].Activity != "123456" ||
+		issue.LogWorks[0].Hours != 1.5 ||
+		!strings.HasPrefix(issue.LogWorks[0].Comment, "Analysis") {
+		log.Println("TEST: wrong work logs", issue.ToString(config, testCalendar))
+		t.Fail()
+	}
+	if len(issue.LinkDuplicates) != 1 ||
+		len(issue.LinkedKeys(LinkTypeBlocks, LinkInward)) != 1 {
+		log.Println("TEST: wrong links", issue.ToString(config, testCalendar))
+		t.Fail()
+	}
+	customers, ok := issue.Field("Customer")
+	if !ok || len(customers.List) != 2 {
+		log.Println("TEST: wrong list field", customers)
+		t.Fail()
+	}
+
+	if issues[1].Assignee != "" || issues[1].Resolution != "Fixed" ||
+		issues[1].Resolved.IsZero() {
+		log.Println("TEST: wrong resolved issue", issues[1].ToString(config, testCalendar))
+		t.Fail()
+	}
+
+	_, err = ParseXml(strings.NewReader("<rss>"), config)
+	if err == nil {
+		log.Println("TEST: invalid XML accepted")
+		t.Fail()
+	}
+}
+
+func TestParseJson(t *testing.T) {
+	f, err := os.Open("testdata/jira_search_page1.json")
+	if err != nil {
+		log.Println("TEST: missing test data", err)
+		t.FailNow()
+	}
+	defer f.Close()
+
+	issues, err := ParseJson(f, DefaultConfig())
+	if err != nil || len(issues) != 2 {
+		log.Println("TEST: wrong issues", len(issues), err)
+		t.FailNow()
+	}
+	issue := issues[0]
+	if issue.Key != "PRJ-1" || issue.CustomActivity != "123456" ||
+		len(issue.LogWorks) != 1 || issue.LogWorks[0].Author != "dev1" ||
+		len(issue.Links) != 2 {
+		log.Println("TEST: wrong issue", issue.ToString(DefaultConfig(), testCalendar))
+		t.Fail()
+	}
+
+	// several pages without names expansion
+	data := `[{"issues": [{"key": "PRJ-1", "fields": {"customfield_1": "A"}}]},
+		{"issues": [{"key": "PRJ-2", "fields": {}}]}]`
+	config := DefaultConfig()
+	config.Customs.Fields["Custom field (customfield_1)"] = FieldString
+	issues, err = ParseJson(strings.NewReader(data), config)
+	if err != nil || len(issues) != 2 {
+		log.Println("TEST: wrong pages", len(issues), err)
+		t.FailNow()
+	}
+	if value, ok := issues[0].Field("customfield_1"); !ok ||
+		value.String != "A" {
+		log.Println("TEST: unnamed custom field not set")
+		t.Fail()
+	}
+
+	data = `{"issues": [{"key": "PRJ-1", "fields": {"created": "yesterday",
+		"worklog": {"worklogs": [{"started": "2021-13-01T10:00:00.000+0000"}]}}}]}`
+	issues, err = ParseJson(strings.NewReader(data), DefaultConfig())
+	if err != nil || len(issues) != 1 {
+		log.Println("TEST: wrong issues with invalid dates", len(issues), err)
+		t.FailNow()
+	}
+	errs := issues[0].DateErrors
+	if len(errs) != 2 || errs[0].Column != "Created" ||
+		errs[0].Value != "yesterday" || errs[1].Column != "Log Work" ||
+		!issues[0].Created.IsZero() {
+		log.Println("TEST: invalid dates not reported", errs)
+		t.Fail()
+	}
+}
+
+func TestFileSourceFormats(t *testing.T) {
+	if InputFormat("export.XML") != InputXml ||
+		InputFormat("search.json") != InputJson ||
+		InputFormat("example.data") != InputCsv {
+		log.Println("TEST: wrong input formats")
+		t.Fail()
+	}
+
+	source := NewFileSource("testdata/jira_export.xml,"+
+		"testdata/jira_search_page*.json", "", DefaultConfig())
+	issues, err := source.Issues()
+	if err != nil || len(issues) != 3 {
+		log.Println("TEST: wrong merged issues", len(issues), err)
+		t.Fail()
+	}
+
+	source = NewFileSource("testdata/jira_export.xml", InputJson,
+		DefaultConfig())
+	_, err = source.Issues()
+	if err == nil {
+		log.Println("TEST: XML parsed as JSON")
+		t.Fail()
+	}
+
+	_, err = ParseInput(strings.NewReader(""), "yaml", DefaultConfig())
+	if err == nil {
+		log.Println("TEST: unknown input format accepted")
+		t.Fail()
+	}
+}